@@ -19,7 +19,16 @@ type AppConfig struct {
 	serverAddr                    string
 	serverContentPath             string
 	serverImportPath              string
+	serverStatusPath              string
+	serverReportPath              string
+	serverWebSubPath              string
+	publicURL                     string
+	webSubLeaseInHours            int64
+	maxBulkImportSizeInMB         int64
 	importQueueDir                string
+	importRetentionInHours        int64
+	contentStoreFile              string
+	contentStoreRetentionInHours  int64
 	fullTextIndex                 bool
 	fullTextIndexDir              string
 	fullTextIndexFile             string
@@ -28,6 +37,16 @@ type AppConfig struct {
 	clientCacheMaxAgeInSeconds    int64
 	templateDir                   string
 	locales                       string
+	providerMaxRetries            int64
+	providerRetryBackoffInSeconds int64
+	providerFailureThreshold      int64
+	providerCoolDownInMinutes     int64
+	providerConcurrency           int64
+	hostRequestIntervalInMillis   int64
+	robotsTxt                     bool
+	ingestReportLimit             int64
+	nearDuplicateMaxDistance      int64
+	maxGeneratedTags              int64
 }
 
 // UnmarshalTOML provides a custom "unmarshaller" so we can keep our fields
@@ -39,7 +58,16 @@ func (c *AppConfig) UnmarshalTOML(data interface{}) error {
 	c.maybeUpdateConfig(d, "ServerAddr", func(val interface{}) { c.serverAddr = val.(string) })
 	c.maybeUpdateConfig(d, "ServerContentPath", func(val interface{}) { c.serverContentPath = val.(string) })
 	c.maybeUpdateConfig(d, "ServerImportPath", func(val interface{}) { c.serverImportPath = val.(string) })
+	c.maybeUpdateConfig(d, "ServerStatusPath", func(val interface{}) { c.serverStatusPath = val.(string) })
+	c.maybeUpdateConfig(d, "ServerReportPath", func(val interface{}) { c.serverReportPath = val.(string) })
+	c.maybeUpdateConfig(d, "ServerWebSubPath", func(val interface{}) { c.serverWebSubPath = val.(string) })
+	c.maybeUpdateConfig(d, "PublicURL", func(val interface{}) { c.publicURL = val.(string) })
+	c.maybeUpdateConfig(d, "WebSubLeaseInHours", func(val interface{}) { c.webSubLeaseInHours = val.(int64) })
+	c.maybeUpdateConfig(d, "MaxBulkImportSizeInMB", func(val interface{}) { c.maxBulkImportSizeInMB = val.(int64) })
 	c.maybeUpdateConfig(d, "ImportQueueDir", func(val interface{}) { c.importQueueDir = val.(string) })
+	c.maybeUpdateConfig(d, "ImportRetentionInHours", func(val interface{}) { c.importRetentionInHours = val.(int64) })
+	c.maybeUpdateConfig(d, "ContentStoreFile", func(val interface{}) { c.contentStoreFile = val.(string) })
+	c.maybeUpdateConfig(d, "ContentStoreRetentionInHours", func(val interface{}) { c.contentStoreRetentionInHours = val.(int64) })
 	c.maybeUpdateConfig(d, "FullTextIndex", func(val interface{}) { c.fullTextIndex = val.(bool) })
 	c.maybeUpdateConfig(d, "FullTextIndexDir", func(val interface{}) { c.fullTextIndexDir = val.(string) })
 	c.maybeUpdateConfig(d, "FullTextIndexFile", func(val interface{}) { c.fullTextIndexFile = val.(string) })
@@ -48,6 +76,16 @@ func (c *AppConfig) UnmarshalTOML(data interface{}) error {
 	c.maybeUpdateConfig(d, "ClientCacheMaxAgeInSeconds", func(val interface{}) { c.clientCacheMaxAgeInSeconds = val.(int64) })
 	c.maybeUpdateConfig(d, "TemplateDir", func(val interface{}) { c.templateDir = val.(string) })
 	c.maybeUpdateConfig(d, "Locales", func(val interface{}) { c.locales = val.(string) })
+	c.maybeUpdateConfig(d, "ProviderMaxRetries", func(val interface{}) { c.providerMaxRetries = val.(int64) })
+	c.maybeUpdateConfig(d, "ProviderRetryBackoffInSeconds", func(val interface{}) { c.providerRetryBackoffInSeconds = val.(int64) })
+	c.maybeUpdateConfig(d, "ProviderFailureThreshold", func(val interface{}) { c.providerFailureThreshold = val.(int64) })
+	c.maybeUpdateConfig(d, "ProviderCoolDownInMinutes", func(val interface{}) { c.providerCoolDownInMinutes = val.(int64) })
+	c.maybeUpdateConfig(d, "ProviderConcurrency", func(val interface{}) { c.providerConcurrency = val.(int64) })
+	c.maybeUpdateConfig(d, "HostRequestIntervalInMillis", func(val interface{}) { c.hostRequestIntervalInMillis = val.(int64) })
+	c.maybeUpdateConfig(d, "RobotsTxt", func(val interface{}) { c.robotsTxt = val.(bool) })
+	c.maybeUpdateConfig(d, "IngestReportLimit", func(val interface{}) { c.ingestReportLimit = val.(int64) })
+	c.maybeUpdateConfig(d, "NearDuplicateMaxDistance", func(val interface{}) { c.nearDuplicateMaxDistance = val.(int64) })
+	c.maybeUpdateConfig(d, "MaxGeneratedTags", func(val interface{}) { c.maxGeneratedTags = val.(int64) })
 	return nil
 }
 
@@ -64,7 +102,15 @@ func Get() *AppConfig {
 		serverAddr:                    ":8080",
 		serverContentPath:             "/crec/content",
 		serverImportPath:              "/crec/import",
+		serverStatusPath:              "/crec/status",
+		serverReportPath:              "/crec/reports",
+		serverWebSubPath:              "/crec/websub",
+		webSubLeaseInHours:            24,
+		maxBulkImportSizeInMB:         100,
 		importQueueDir:                "import",
+		importRetentionInHours:        168,
+		contentStoreFile:              "crec.db",
+		contentStoreRetentionInHours:  720,
 		fullTextIndex:                 true,
 		fullTextIndexDir:              "index",
 		fullTextIndexFile:             "crec.bleve",
@@ -72,7 +118,16 @@ func Get() *AppConfig {
 		clientCacheMaxAgeInSeconds:    120,
 		providerRegistryDir:           "provider-registry",
 		templateDir:                   "template",
-		locales:                       "en, en-US"}
+		locales:                       "en, en-US",
+		providerMaxRetries:            2,
+		providerRetryBackoffInSeconds: 1,
+		providerFailureThreshold:      5,
+		providerCoolDownInMinutes:     30,
+		providerConcurrency:           10,
+		hostRequestIntervalInMillis:   1000,
+		ingestReportLimit:             100,
+		nearDuplicateMaxDistance:      3,
+		maxGeneratedTags:              3}
 
 	port := os.Getenv("PORT")
 	if port != "" {
@@ -107,11 +162,59 @@ func (c *AppConfig) GetImportPath() string {
 	return c.serverImportPath
 }
 
+// GetStatusPath returns the URL path to handle status requests e.g. /crec/status
+func (c *AppConfig) GetStatusPath() string {
+	return c.serverStatusPath
+}
+
+// GetReportPath returns the URL path to handle ingestion report requests e.g. /crec/reports
+func (c *AppConfig) GetReportPath() string {
+	return c.serverReportPath
+}
+
+// GetWebSubPath returns the URL path to handle WebSub callbacks e.g. /crec/websub
+func (c *AppConfig) GetWebSubPath() string {
+	return c.serverWebSubPath
+}
+
+// GetPublicURL returns the URL this server is publicly reachable at e.g. https://crec.example.com,
+// empty if it isn't reachable by WebSub hubs
+func (c *AppConfig) GetPublicURL() string {
+	return c.publicURL
+}
+
+// GetWebSubLease returns the requested duration of WebSub subscriptions
+func (c *AppConfig) GetWebSubLease() time.Duration {
+	return time.Hour * time.Duration(c.webSubLeaseInHours)
+}
+
+// GetMaxBulkImportSize returns the maximum size in bytes of a bulk import request
+func (c *AppConfig) GetMaxBulkImportSize() int64 {
+	return c.maxBulkImportSizeInMB * 1024 * 1024
+}
+
 // GetImportQueueDir returns the directory path to store imported content e.g. import
 func (c *AppConfig) GetImportQueueDir() string {
 	return c.importQueueDir
 }
 
+// GetImportRetention returns the time imported content is retained, zero if it should be kept forever
+func (c *AppConfig) GetImportRetention() time.Duration {
+	return time.Hour * time.Duration(c.importRetentionInHours)
+}
+
+// GetContentStoreFile returns the path of the file persisting ingested content e.g. crec.db,
+// empty if content should not be persisted
+func (c *AppConfig) GetContentStoreFile() string {
+	return c.contentStoreFile
+}
+
+// GetContentStoreRetention returns the time persisted content is retained after it was
+// last ingested, zero if it should be kept forever
+func (c *AppConfig) GetContentStoreRetention() time.Duration {
+	return time.Hour * time.Duration(c.contentStoreRetentionInHours)
+}
+
 // FullTextIndexActive returns true if a full-text index should be created, otherwise false.
 func (c *AppConfig) FullTextIndexActive() bool {
 	return c.fullTextIndex
@@ -157,6 +260,63 @@ func (c *AppConfig) GetLocales() string {
 	return c.locales
 }
 
+// GetProviderMaxRetries returns the number of times a failed content fetch is retried
+func (c *AppConfig) GetProviderMaxRetries() int {
+	return int(c.providerMaxRetries)
+}
+
+// GetProviderRetryBackoff returns the delay before the first retry of a failed
+// content fetch, doubled for every subsequent retry
+func (c *AppConfig) GetProviderRetryBackoff() time.Duration {
+	return time.Second * time.Duration(c.providerRetryBackoffInSeconds)
+}
+
+// GetProviderFailureThreshold returns the number of consecutive failures after which
+// a provider is no longer fetched for the duration of the cool-down
+func (c *AppConfig) GetProviderFailureThreshold() int {
+	return int(c.providerFailureThreshold)
+}
+
+// GetProviderCoolDown returns the time a repeatedly failing provider is no longer fetched
+func (c *AppConfig) GetProviderCoolDown() time.Duration {
+	return time.Minute * time.Duration(c.providerCoolDownInMinutes)
+}
+
+// GetProviderConcurrency returns the maximum number of providers refreshed concurrently,
+// zero if unlimited
+func (c *AppConfig) GetProviderConcurrency() int {
+	return int(c.providerConcurrency)
+}
+
+// GetHostRequestInterval returns the minimum time between requests to the same host
+func (c *AppConfig) GetHostRequestInterval() time.Duration {
+	return time.Millisecond * time.Duration(c.hostRequestIntervalInMillis)
+}
+
+// RobotsTxtActive returns true if content should only be fetched if permitted by
+// the host's robots.txt, otherwise false.
+func (c *AppConfig) RobotsTxtActive() bool {
+	return c.robotsTxt
+}
+
+// GetIngestReportLimit returns the number of most recent ingestion reports kept in memory
+func (c *AppConfig) GetIngestReportLimit() int {
+	return int(c.ingestReportLimit)
+}
+
+// GetNearDuplicateMaxDistance returns the maximum number of bits in which the
+// fingerprints of near-duplicate content may differ, negative if near-duplicate
+// detection is disabled
+func (c *AppConfig) GetNearDuplicateMaxDistance() int {
+	return int(c.nearDuplicateMaxDistance)
+}
+
+// GetMaxGeneratedTags returns the maximum number of tags extracted from the title
+// and excerpt of untagged content, zero if tags shouldn't be extracted
+func (c *AppConfig) GetMaxGeneratedTags() int {
+	return int(c.maxGeneratedTags)
+}
+
 // Create returns a config instance with the provided parameters
 func Create(secret string, templateDir string, importQueueDir string,
 	fullTextIndexDir string, fullTextIndexFile string) *AppConfig {
@@ -3,6 +3,7 @@ package config
 import (
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestGetConfigReturnsMeaningfulDefaults(t *testing.T) {
@@ -10,7 +11,15 @@ func TestGetConfigReturnsMeaningfulDefaults(t *testing.T) {
 		serverAddr:                    ":8080",
 		serverContentPath:             "/crec/content",
 		serverImportPath:              "/crec/import",
+		serverStatusPath:              "/crec/status",
+		serverReportPath:              "/crec/reports",
+		serverWebSubPath:              "/crec/websub",
+		webSubLeaseInHours:            24,
+		maxBulkImportSizeInMB:         100,
 		importQueueDir:                "import",
+		importRetentionInHours:        168,
+		contentStoreFile:              "crec.db",
+		contentStoreRetentionInHours:  720,
 		fullTextIndex:                 true,
 		fullTextIndexDir:              "index",
 		fullTextIndexFile:             "crec.bleve",
@@ -18,7 +27,16 @@ func TestGetConfigReturnsMeaningfulDefaults(t *testing.T) {
 		clientCacheMaxAgeInSeconds:    120,
 		providerRegistryDir:           "provider-registry",
 		templateDir:                   "template",
-		locales:                       "en, en-US"}
+		locales:                       "en, en-US",
+		providerMaxRetries:            2,
+		providerRetryBackoffInSeconds: 1,
+		providerFailureThreshold:      5,
+		providerCoolDownInMinutes:     30,
+		providerConcurrency:           10,
+		hostRequestIntervalInMillis:   1000,
+		ingestReportLimit:             100,
+		nearDuplicateMaxDistance:      3,
+		maxGeneratedTags:              3}
 
 	got := Get()
 
@@ -32,7 +50,16 @@ func TestUnmarshalTOML(t *testing.T) {
 		"ServerAddr":                    "_serverAddr",
 		"ServerContentPath":             "_serverContentPath",
 		"ServerImportPath":              "_serverImportPath",
+		"ServerStatusPath":              "_serverStatusPath",
+		"ServerReportPath":              "_serverReportPath",
+		"ServerWebSubPath":              "_serverWebSubPath",
+		"PublicURL":                     "_publicURL",
+		"WebSubLeaseInHours":            int64(7),
+		"MaxBulkImportSizeInMB":         int64(4),
 		"ImportQueueDir":                "_importQueueDir",
+		"ImportRetentionInHours":        int64(3),
+		"ContentStoreFile":              "_contentStoreFile",
+		"ContentStoreRetentionInHours":  int64(6),
 		"FullTextIndex":                 true,
 		"FullTextIndexDir":              "_indexDir",
 		"FullTextIndexFile":             "_indexFile",
@@ -40,13 +67,32 @@ func TestUnmarshalTOML(t *testing.T) {
 		"ClientCacheMaxAgeInSeconds":    int64(2),
 		"ProviderRegistryDir":           "_providerRegistryDir",
 		"TemplateDir":                   "template",
-		"Locales":                       "en, en-US"}
+		"Locales":                       "en, en-US",
+		"ProviderMaxRetries":            int64(2),
+		"ProviderRetryBackoffInSeconds": int64(1),
+		"ProviderFailureThreshold":      int64(5),
+		"ProviderCoolDownInMinutes":     int64(30),
+		"ProviderConcurrency":           int64(8),
+		"HostRequestIntervalInMillis":   int64(9),
+		"RobotsTxt":                     true,
+		"IngestReportLimit":             int64(11),
+		"NearDuplicateMaxDistance":      int64(12),
+		"MaxGeneratedTags":              int64(13)}
 
 	want := AppConfig{
 		serverAddr:                    "_serverAddr",
 		serverContentPath:             "_serverContentPath",
 		serverImportPath:              "_serverImportPath",
+		serverStatusPath:              "_serverStatusPath",
+		serverReportPath:              "_serverReportPath",
+		serverWebSubPath:              "_serverWebSubPath",
+		publicURL:                     "_publicURL",
+		webSubLeaseInHours:            int64(7),
+		maxBulkImportSizeInMB:         int64(4),
 		importQueueDir:                "_importQueueDir",
+		importRetentionInHours:        int64(3),
+		contentStoreFile:              "_contentStoreFile",
+		contentStoreRetentionInHours:  int64(6),
 		fullTextIndex:                 true,
 		fullTextIndexDir:              "_indexDir",
 		fullTextIndexFile:             "_indexFile",
@@ -54,7 +100,17 @@ func TestUnmarshalTOML(t *testing.T) {
 		clientCacheMaxAgeInSeconds:    int64(2),
 		providerRegistryDir:           "_providerRegistryDir",
 		templateDir:                   "template",
-		locales:                       "en, en-US"}
+		locales:                       "en, en-US",
+		providerMaxRetries:            2,
+		providerRetryBackoffInSeconds: 1,
+		providerFailureThreshold:      5,
+		providerCoolDownInMinutes:     30,
+		providerConcurrency:           8,
+		hostRequestIntervalInMillis:   9,
+		robotsTxt:                     true,
+		ingestReportLimit:             11,
+		nearDuplicateMaxDistance:      12,
+		maxGeneratedTags:              13}
 
 	got := &AppConfig{}
 	got.UnmarshalTOML(toml)
@@ -70,7 +126,16 @@ func TestGetterMethods(t *testing.T) {
 		serverAddr:                    ":8080",
 		serverContentPath:             "/crec/content",
 		serverImportPath:              "/crec/import",
+		serverStatusPath:              "/crec/status",
+		serverReportPath:              "/crec/reports",
+		serverWebSubPath:              "/crec/websub",
+		publicURL:                     "https://crec.example.com",
+		webSubLeaseInHours:            24,
+		maxBulkImportSizeInMB:         100,
 		importQueueDir:                "import",
+		importRetentionInHours:        168,
+		contentStoreFile:              "crec.db",
+		contentStoreRetentionInHours:  720,
 		fullTextIndex:                 true,
 		fullTextIndexDir:              "index",
 		fullTextIndexFile:             "crec.bleve",
@@ -79,12 +144,31 @@ func TestGetterMethods(t *testing.T) {
 		providerRegistryDir:           "provider-registry",
 		templateDir:                   "template",
 		secret:                        "dont-do-this",
-		locales:                       "en, en-US"}
+		locales:                       "en, en-US",
+		providerMaxRetries:            2,
+		providerRetryBackoffInSeconds: 1,
+		providerFailureThreshold:      5,
+		providerCoolDownInMinutes:     30,
+		providerConcurrency:           10,
+		hostRequestIntervalInMillis:   1000,
+		robotsTxt:                     true,
+		ingestReportLimit:             100,
+		nearDuplicateMaxDistance:      3,
+		maxGeneratedTags:              3}
 
 	assertEquals(t, config.serverAddr, config.GetAddr())
 	assertEquals(t, config.serverContentPath, config.GetContentPath())
 	assertEquals(t, config.serverImportPath, config.GetImportPath())
+	assertEquals(t, config.serverStatusPath, config.GetStatusPath())
+	assertEquals(t, config.serverReportPath, config.GetReportPath())
+	assertEquals(t, config.serverWebSubPath, config.GetWebSubPath())
+	assertEquals(t, config.publicURL, config.GetPublicURL())
+	assertEquals(t, config.webSubLeaseInHours, int64(config.GetWebSubLease().Hours()))
+	assertEquals(t, config.maxBulkImportSizeInMB*1024*1024, config.GetMaxBulkImportSize())
 	assertEquals(t, config.importQueueDir, config.GetImportQueueDir())
+	assertEquals(t, config.importRetentionInHours, int64(config.GetImportRetention().Hours()))
+	assertEquals(t, config.contentStoreFile, config.GetContentStoreFile())
+	assertEquals(t, config.contentStoreRetentionInHours, int64(config.GetContentStoreRetention().Hours()))
 	assertEquals(t, config.fullTextIndex, config.FullTextIndexActive())
 	assertEquals(t, config.fullTextIndexDir, config.GetFullTextIndexDir())
 	assertEquals(t, config.fullTextIndexFile, config.GetFullTextIndexFile())
@@ -94,6 +178,16 @@ func TestGetterMethods(t *testing.T) {
 	assertEquals(t, config.templateDir, config.GetTemplateDir())
 	assertEquals(t, config.secret, config.GetSecret())
 	assertEquals(t, config.locales, config.GetLocales())
+	assertEquals(t, config.providerMaxRetries, int64(config.GetProviderMaxRetries()))
+	assertEquals(t, config.providerRetryBackoffInSeconds, int64(config.GetProviderRetryBackoff().Seconds()))
+	assertEquals(t, config.providerFailureThreshold, int64(config.GetProviderFailureThreshold()))
+	assertEquals(t, config.providerCoolDownInMinutes, int64(config.GetProviderCoolDown().Minutes()))
+	assertEquals(t, config.providerConcurrency, int64(config.GetProviderConcurrency()))
+	assertEquals(t, config.hostRequestIntervalInMillis, int64(config.GetHostRequestInterval()/time.Millisecond))
+	assertEquals(t, config.robotsTxt, config.RobotsTxtActive())
+	assertEquals(t, config.ingestReportLimit, int64(config.GetIngestReportLimit()))
+	assertEquals(t, config.nearDuplicateMaxDistance, int64(config.GetNearDuplicateMaxDistance()))
+	assertEquals(t, config.maxGeneratedTags, int64(config.GetMaxGeneratedTags()))
 }
 
 func TestCreateMethods(t *testing.T) {
@@ -0,0 +1,121 @@
+package content
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Query parameters used for tracking, removed from the URLs of all providers
+// (see Provider.TrackingParams). A trailing * matches any suffix.
+var defaultTrackingParams = []string{"utm_*", "fbclid", "gclid", "dclid", "msclkid", "yclid",
+	"mc_cid", "mc_eid", "_ga", "_hsenc", "_hsmi", "igshid", "ns_*"}
+
+// canonicalizeURL returns the canonical form of the URL: the scheme and host in
+// lower case without default port, without fragment and tracking parameters, and
+// with the remaining query parameters sorted. URLs other than absolute HTTP(S) URLs
+// are returned unchanged.
+func canonicalizeURL(s string, trackingParams []string) string {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return s
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return s
+	}
+
+	host, port := strings.ToLower(u.Host), ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host, port = h, p
+	}
+	host = strings.TrimSuffix(host, ".")
+	if port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host = net.JoinHostPort(host, port)
+	}
+	u.Host = host
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+
+	query := u.Query()
+	for param := range query {
+		if isTrackingParam(param, trackingParams) {
+			query.Del(param)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func isTrackingParam(param string, trackingParams []string) bool {
+	param = strings.ToLower(param)
+	for _, p := range trackingParams {
+		p = strings.ToLower(p)
+		if p == param || (strings.HasSuffix(p, "*") && strings.HasPrefix(param, strings.TrimSuffix(p, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// Second-level labels under which country code top-level domains register
+// domains e.g. co.uk or com.au
+var secondLevelLabels = map[string]bool{"ac": true, "co": true, "com": true, "edu": true, "gov": true,
+	"net": true, "or": true, "org": true, "ne": true, "gob": true}
+
+// registrableDomain returns the domain of the host registered with a registrar
+// e.g. example.co.uk for www.example.co.uk
+func registrableDomain(host string) string {
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(host), "."), ".")
+	n := 2
+	if len(labels) > 2 && len(labels[len(labels)-1]) == 2 && secondLevelLabels[labels[len(labels)-2]] {
+		n = 3
+	}
+	if len(labels) <= n {
+		return strings.Join(labels, ".")
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
+
+// canonicalizeContent canonicalizes the URLs of the provider's content. IDs which
+// are URLs (e.g. derived from the link of a feed item) are canonicalized as well,
+// so the same item is ingested only once, regardless of the tracking parameters.
+func canonicalizeContent(provider *Provider, content []*Content) []*Content {
+	trackingParams := provider.GetTrackingParams()
+	for _, c := range content {
+		if isValidURL(c.ID) {
+			c.ID = canonicalizeURL(c.ID, trackingParams)
+		}
+		c.URL = canonicalizeURL(c.URL, trackingParams)
+	}
+	return dedupeContent(content)
+}
+
+// canonicalizeIDs canonicalizes the IDs which are URLs, so they match the IDs of
+// canonicalized content
+func canonicalizeIDs(provider *Provider, ids []string) []string {
+	canonical := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if isValidURL(id) {
+			id = canonicalizeURL(id, provider.GetTrackingParams())
+		}
+		canonical = append(canonical, id)
+	}
+	return canonical
+}
+
+// dedupeContent returns the content without items whose ID appeared before
+func dedupeContent(content []*Content) []*Content {
+	ids := make(map[string]bool)
+	deduped := make([]*Content, 0, len(content))
+	for _, c := range content {
+		if ids[c.ID] {
+			continue
+		}
+		ids[c.ID] = true
+		deduped = append(deduped, c)
+	}
+	return deduped
+}
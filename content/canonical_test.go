@@ -0,0 +1,92 @@
+package content
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonicalizeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"http://www.nytimes.com/2017/03/10/science/space-dust.html?partner=rss&emc=rss", "http://www.nytimes.com/2017/03/10/science/space-dust.html"},
+		{"HTTPS://Example.COM:443/a?utm_source=feed&utm_medium=rss&id=1#comments", "https://example.com/a?id=1"},
+		{"http://example.com:80?b=2&a=1&fbclid=x", "http://example.com/?a=1&b=2"},
+		{"http://example.com.:8080/A", "http://example.com:8080/A"},
+		{"urn:uuid:1225c695", "urn:uuid:1225c695"},
+		{"/relative?utm_source=feed", "/relative?utm_source=feed"},
+	}
+
+	for _, test := range tests {
+		got := canonicalizeURL(test.url, append(defaultTrackingParams, "partner", "EMC"))
+		if got != test.want {
+			t.Errorf("Expected %v for %v, but got %v", test.want, test.url, got)
+		}
+	}
+}
+
+func TestIngestSyndicationFeedCanonicalizesURLs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `<rss><channel>
+			<item><link>http://example.com/0?partner=rss&amp;utm_source=feed</link></item>
+			<item><link>http://EXAMPLE.com/0?utm_campaign=other</link></item>
+			<item><guid>1</guid><link>http://example.com/1?cmp=rss</link></item>
+			<item><guid>http://example.com/2?cmp=rss</guid><link>http://example.com/2?cmp=rss</link></item>
+		</channel></rss>`)
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test", ContentURL: ts.URL, TrackingParams: []string{"partner", "cmp"}}
+	content, _, _, err := ingestFromURL(p, &http.Client{}, nil, &Index{}, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, content, "http://example.com/0", "1", "http://example.com/2")
+	for i, want := range []string{"http://example.com/0", "http://example.com/1", "http://example.com/2"} {
+		if content[i].URL != want {
+			t.Errorf("Expected URL %v, but got %v", want, content[i].URL)
+		}
+	}
+}
+
+func TestParseContentCanonicalizesNativeContent(t *testing.T) {
+	p := &Provider{ID: "test", Native: true, TrackingParams: []string{"partner", "emc"}}
+	content, err := parseContent([]byte(`[{"id":"http://example.com/0?partner=rss&emc=rss","url":"http://example.com/0?partner=rss&emc=rss"}]`), p, nil, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, content, "http://example.com/0")
+	if content[0].URL != "http://example.com/0" {
+		t.Errorf("Expected canonical URL, but got %v", content[0].URL)
+	}
+}
+
+func TestIngestFromQueueCanonicalizesPushedContent(t *testing.T) {
+	config := &TestConfig{}
+	provider := &Provider{ID: "test-canonical", TrackingParams: []string{"partner", "emc"}}
+	path := filepath.Join(config.GetImportQueueDir(), provider.ID)
+	defer os.RemoveAll(path)
+
+	Enqueue(config, []byte(`[{"id":"http://example.com/0?partner=rss&emc=rss","url":"http://example.com/0?partner=rss&emc=rss"},
+		{"id":"http://example.com/1","url":"http://example.com/1"}]`), provider.ID)
+	content, err := ingestFromQueue(config, provider, []*Content{}, createIndexWithID(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, content, "http://example.com/0", "http://example.com/1")
+	if content[0].URL != "http://example.com/0" {
+		t.Errorf("Expected canonical URL, but got %v", content[0].URL)
+	}
+
+	EnqueueRetraction(config, []string{"http://example.com/1?partner=rss"}, provider.ID)
+	content, err = ingestFromQueue(config, provider, []*Content{}, createIndexWithID(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, content, "http://example.com/0")
+}
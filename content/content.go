@@ -1,6 +1,7 @@
 package content
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -48,11 +49,15 @@ type Content struct {
 	Author string `json:"author,omitempty"`
 
 	// Publication date
-	Published string `json:"published_timestamp,omitempty"`
+	Published *Timestamp `json:"published_timestamp,omitempty"`
 
 	// Tags and categories applied to this content
 	Tags []string `json:"tags,omitempty"`
 
+	// Tags extracted from the title and excerpt of content without tags of its
+	// own. Generated tags are part of Tags as well.
+	GeneratedTags []string `json:"generated_tags,omitempty"`
+
 	// Language the content is written in
 	Language string `json:"-"`
 
@@ -72,6 +77,51 @@ type Content struct {
 	CType Type `json:"type,omitempty"`
 }
 
+// Timestamp is a publication date, serialized in RFC 3339 (UTC). Dates in any
+// of the accepted formats are parsed when unmarshalling (see timestampLayouts).
+type Timestamp struct {
+	time.Time
+
+	// Original value, if it isn't a valid date
+	invalid string
+}
+
+// NewTimestamp creates a timestamp of the provided time, normalized to UTC
+func NewTimestamp(t time.Time) *Timestamp {
+	return &Timestamp{Time: t.UTC()}
+}
+
+// Valid returns true if the timestamp holds a valid date
+func (t *Timestamp) Valid() bool {
+	return t.invalid == ""
+}
+
+// MarshalJSON serializes the timestamp in RFC 3339
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.invalid != "" {
+		return json.Marshal(t.invalid)
+	}
+	return json.Marshal(t.UTC().Format(time.RFC3339))
+}
+
+// UnmarshalJSON parses a date in any of the accepted formats. Invalid dates
+// don't fail unmarshalling, but result in an invalid timestamp (see Valid).
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		*t = Timestamp{invalid: string(data)}
+		return nil
+	}
+
+	parsed, err := ParseTimestamp(s)
+	if err != nil {
+		*t = Timestamp{invalid: s}
+		return nil
+	}
+	*t = Timestamp{Time: parsed.UTC()}
+	return nil
+}
+
 func (c *Content) String() string {
 	return fmt.Sprintf("Source: %s: Title: %s", c.Source, c.Title)
 }
@@ -81,9 +131,22 @@ type Config interface {
 	GetFullTextIndexDir() string
 	GetFullTextIndexFile() string
 	GetImportQueueDir() string
+	GetImportRetention() time.Duration
+	GetContentStoreFile() string
+	GetContentStoreRetention() time.Duration
 	GetIndexRefreshInterval() time.Duration
 	GetLocales() string
 	GetProviderRegistryDir() string
+	GetProviderMaxRetries() int
+	GetProviderRetryBackoff() time.Duration
+	GetProviderFailureThreshold() int
+	GetProviderCoolDown() time.Duration
+	GetProviderConcurrency() int
+	GetHostRequestInterval() time.Duration
+	RobotsTxtActive() bool
+	GetIngestReportLimit() int
+	GetNearDuplicateMaxDistance() int
+	GetMaxGeneratedTags() int
 	FullTextIndexActive() bool
 }
 
@@ -98,6 +161,21 @@ func Filter(c []*Content, f func(*Content) bool) []*Content {
 	return vsf
 }
 
+// PublishedFilter returns a filter function which retains the content if it was
+// published within the provided bounds. Zero times are unbounded. Content without
+// a publication date is only retained if both bounds are zero.
+func PublishedFilter(since time.Time, until time.Time) func(*Content) bool {
+	return func(c *Content) bool {
+		if since.IsZero() && until.IsZero() {
+			return true
+		}
+		if c.Published == nil || !c.Published.Valid() {
+			return false
+		}
+		return !c.Published.Before(since) && (until.IsZero() || !c.Published.After(until))
+	}
+}
+
 // AnyTagFilter returns a filter function which retains the content if any
 // of the provided tags is present
 func AnyTagFilter(tags map[string]bool) func(*Content) bool {
@@ -1,6 +1,7 @@
 package content
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"log"
 	"os"
@@ -27,6 +28,15 @@ func (t *TestConfig) GetFullTextIndexFile() string {
 func (t *TestConfig) GetImportQueueDir() string {
 	return "import"
 }
+func (t *TestConfig) GetImportRetention() time.Duration {
+	return time.Hour
+}
+func (t *TestConfig) GetContentStoreFile() string {
+	return filepath.FromSlash(os.TempDir() + "/crec-test.db")
+}
+func (t *TestConfig) GetContentStoreRetention() time.Duration {
+	return time.Hour
+}
 func (t *TestConfig) GetIndexRefreshInterval() time.Duration {
 	return time.Minute * time.Duration(int64(5))
 }
@@ -39,6 +49,36 @@ func (t *TestConfig) GetLocales() string {
 func (t *TestConfig) GetProviderRegistryDir() string {
 	return providerDir
 }
+func (t *TestConfig) GetProviderMaxRetries() int {
+	return 1
+}
+func (t *TestConfig) GetProviderRetryBackoff() time.Duration {
+	return time.Millisecond
+}
+func (t *TestConfig) GetProviderFailureThreshold() int {
+	return 2
+}
+func (t *TestConfig) GetProviderCoolDown() time.Duration {
+	return time.Minute
+}
+func (t *TestConfig) GetProviderConcurrency() int {
+	return 2
+}
+func (t *TestConfig) GetHostRequestInterval() time.Duration {
+	return 0
+}
+func (t *TestConfig) RobotsTxtActive() bool {
+	return false
+}
+func (t *TestConfig) GetIngestReportLimit() int {
+	return 10
+}
+func (t *TestConfig) GetNearDuplicateMaxDistance() int {
+	return 3
+}
+func (t *TestConfig) GetMaxGeneratedTags() int {
+	return 3
+}
 
 func before() {
 	providerDir = filepath.FromSlash(os.TempDir() + "test-provider-registry")
@@ -59,6 +99,7 @@ func tearDown() {
 	config := &TestConfig{}
 	cleanUp(config, &Index{})
 	os.RemoveAll(config.GetImportQueueDir())
+	os.Remove(config.GetContentStoreFile())
 
 	if providerDir != "" {
 		os.RemoveAll(providerDir)
@@ -107,6 +148,45 @@ func TestAllTagFilter(t *testing.T) {
 	}
 }
 
+func TestPublishedFilter(t *testing.T) {
+	content := []*Content{
+		{ID: "0", Published: NewTimestamp(time.Date(2017, 9, 16, 0, 0, 0, 0, time.UTC))},
+		{ID: "1", Published: NewTimestamp(time.Date(2017, 9, 17, 0, 0, 0, 0, time.UTC))},
+		{ID: "2", Published: NewTimestamp(time.Date(2017, 9, 18, 0, 0, 0, 0, time.UTC))},
+		{ID: "3"}}
+
+	assertContentIDs(t, Filter(content, PublishedFilter(time.Time{}, time.Time{})), "0", "1", "2", "3")
+	assertContentIDs(t, Filter(content, PublishedFilter(time.Date(2017, 9, 17, 0, 0, 0, 0, time.UTC), time.Time{})), "1", "2")
+	assertContentIDs(t, Filter(content, PublishedFilter(time.Time{}, time.Date(2017, 9, 17, 0, 0, 0, 0, time.UTC))), "0", "1")
+}
+
+func TestTimestampJSON(t *testing.T) {
+	var c Content
+	err := json.Unmarshal([]byte(`{"published_timestamp":"Sun, 17 Sep 2017 15:53:05 +0200"}`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Published.Valid() || !c.Published.Equal(time.Date(2017, 9, 17, 13, 53, 5, 0, time.UTC)) {
+		t.Errorf("Expected date to be parsed, but got %v", c.Published)
+	}
+
+	bytes, err := json.Marshal(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != `{"published_timestamp":"2017-09-17T13:53:05Z"}` {
+		t.Errorf("Expected date in RFC 3339, but got %v", string(bytes))
+	}
+
+	err = json.Unmarshal([]byte(`{"published_timestamp":"yesterday"}`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Published.Valid() {
+		t.Error("Expected invalid timestamp")
+	}
+}
+
 func TestTransformContent(t *testing.T) {
 	content := []*Content{{ID: "0"}, {ID: "1"}, {ID: "2"}}
 
@@ -0,0 +1,210 @@
+package content
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Minimum number of words in the title and excerpt of content for its
+// fingerprint to be meaningful. Shorter content is never considered a
+// near-duplicate.
+const minFingerprintWords = 5
+
+// fingerprint returns the 64-bit simhash of the words in the content's title
+// and excerpt, ignoring case and punctuation. The fingerprints of similar texts
+// differ in few bits. False is returned if the content has too few words.
+func fingerprint(c *Content) (uint64, bool) {
+	words := strings.FieldsFunc(strings.ToLower(c.Title+" "+c.Excerpt), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) < minFingerprintWords {
+		return 0, false
+	}
+
+	var weights [64]int
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		for bit := uint(0); bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var f uint64
+	for bit := uint(0); bit < 64; bit++ {
+		if weights[bit] > 0 {
+			f |= 1 << bit
+		}
+	}
+	return f, true
+}
+
+// fingerprintCache holds the fingerprints of the content of the most recent view,
+// shared by all views of an index, so that only the fingerprints of new content
+// need to be computed when a view is created
+type fingerprintCache struct {
+	fingerprints map[*Content]uint64
+	valid        map[*Content]bool
+	mux          sync.Mutex
+}
+
+func newFingerprintCache() *fingerprintCache {
+	return &fingerprintCache{fingerprints: make(map[*Content]uint64), valid: make(map[*Content]bool)}
+}
+
+// update returns the fingerprints of the provided content (see fingerprint), and
+// retains only these in the cache. The fingerprints are computed if there's no cache.
+func (f *fingerprintCache) update(content []*Content) ([]uint64, []bool) {
+	fingerprints := make([]uint64, len(content))
+	valid := make([]bool, len(content))
+	if f == nil {
+		for i, c := range content {
+			fingerprints[i], valid[i] = fingerprint(c)
+		}
+		return fingerprints, valid
+	}
+
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	cached, cachedValid := make(map[*Content]uint64), make(map[*Content]bool)
+	for i, c := range content {
+		if _, ok := f.valid[c]; ok {
+			fingerprints[i], valid[i] = f.fingerprints[c], f.valid[c]
+		} else {
+			fingerprints[i], valid[i] = fingerprint(c)
+		}
+		cached[c], cachedValid[c] = fingerprints[i], valid[i]
+	}
+	f.fingerprints, f.valid = cached, cachedValid
+	return fingerprints, valid
+}
+
+// hammingDistance returns the number of bits in which the fingerprints differ
+func hammingDistance(a, b uint64) int {
+	distance := 0
+	for x := a ^ b; x != 0; x &= x - 1 {
+		distance++
+	}
+	return distance
+}
+
+// findNearDuplicates returns the groups of content from different providers whose
+// fingerprints differ in at most maxDistance bits. Groups hold at most one item
+// of each provider, so items are only added to a group if their provider isn't
+// part of it yet. Each group is ordered by preference (see preferred), so its
+// first item is the canonical one. Fingerprints
+// are split into maxDistance+1 bands, of which near-duplicates share at least one,
+// so only content sharing a band needs to be compared. Fingerprints are taken
+// from the provided cache, which may be nil.
+func findNearDuplicates(content []*Content, priorities map[string]int, maxDistance int, cache *fingerprintCache) [][]*Content {
+	fingerprints, valid := cache.update(content)
+
+	parents := make([]int, len(content))
+	for i := range parents {
+		parents[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		if parents[i] != i {
+			parents[i] = root(parents[i])
+		}
+		return parents[i]
+	}
+	// Providers of the content in each group, keyed by the group's root
+	sources := make(map[int]map[string]bool)
+	union := func(i, j int) {
+		ri, rj := root(i), root(j)
+		if ri == rj {
+			return
+		}
+		si, sj := sources[ri], sources[rj]
+		if si == nil {
+			si = map[string]bool{content[ri].Source: true}
+		}
+		if sj == nil {
+			sj = map[string]bool{content[rj].Source: true}
+		}
+		for source := range si {
+			if sj[source] {
+				return
+			}
+		}
+		for source := range si {
+			sj[source] = true
+		}
+		parents[ri] = rj
+		sources[rj] = sj
+		delete(sources, ri)
+	}
+
+	bands := maxDistance + 1
+	if bands > 64 {
+		bands = 64
+	}
+	width := uint(64 / bands)
+	for band := 0; band < bands; band++ {
+		shift := uint(band) * width
+		mask := uint64(1)<<width - 1
+		if band == bands-1 {
+			mask = ^uint64(0) >> shift
+		}
+
+		buckets := make(map[uint64][]int)
+		for i, f := range fingerprints {
+			if !valid[i] {
+				continue
+			}
+			key := (f >> shift) & mask
+			for _, j := range buckets[key] {
+				if hammingDistance(f, fingerprints[j]) <= maxDistance {
+					union(i, j)
+				}
+			}
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	members := make(map[int][]*Content)
+	roots := make([]int, 0)
+	for i, c := range content {
+		r := root(i)
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], c)
+	}
+
+	groups := make([][]*Content, 0)
+	for _, r := range roots {
+		group := members[r]
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool {
+			return preferred(group[i], group[j], priorities)
+		})
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// preferred returns true if content a should be recommended instead of its
+// near-duplicate b: content of providers with a higher priority is preferred,
+// followed by the earliest published content.
+func preferred(a *Content, b *Content, priorities map[string]int) bool {
+	if pa, pb := priorities[a.Source], priorities[b.Source]; pa != pb {
+		return pa > pb
+	}
+	if a.Published != nil && b.Published != nil {
+		return a.Published.Before(b.Published.Time)
+	}
+	return a.Published != nil && b.Published == nil
+}
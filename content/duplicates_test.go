@@ -0,0 +1,88 @@
+package content
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	a, ok := fingerprint(&Content{Title: "Storm hits the coast", Excerpt: "Thousands of homes lost power overnight."})
+	if !ok {
+		t.Fatal("Expected fingerprint")
+	}
+	b, _ := fingerprint(&Content{Title: "STORM HITS THE COAST:", Excerpt: "Thousands of homes lost power, overnight"})
+	if a != b {
+		t.Errorf("Expected case and punctuation to be ignored, but got distance %v", hammingDistance(a, b))
+	}
+	c, _ := fingerprint(&Content{Title: "Local team wins the championship", Excerpt: "Fans celebrated in the streets"})
+	if hammingDistance(a, c) <= 3 {
+		t.Errorf("Expected unrelated content to differ, but got distance %v", hammingDistance(a, c))
+	}
+	if _, ok := fingerprint(&Content{Title: "Breaking news"}); ok {
+		t.Error("Expected no fingerprint for content with too few words")
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	if d := hammingDistance(0xF0, 0x0F); d != 8 {
+		t.Errorf("Expected distance 8, but got %v", d)
+	}
+	if d := hammingDistance(1<<63, 1<<63); d != 0 {
+		t.Errorf("Expected distance 0, but got %v", d)
+	}
+}
+
+func TestFindNearDuplicates(t *testing.T) {
+	earlier := NewTimestamp(time.Date(2017, 9, 16, 0, 0, 0, 0, time.UTC))
+	later := NewTimestamp(time.Date(2017, 9, 17, 0, 0, 0, 0, time.UTC))
+	content := []*Content{
+		&Content{ID: "0", Source: "p1", Title: "Storm hits the coast", Excerpt: "Thousands of homes lost power overnight", Published: later},
+		&Content{ID: "1", Source: "p1", Title: "Storm hits the coast", Excerpt: "Thousands of homes lost power overnight"},
+		&Content{ID: "2", Source: "p2", Title: "Storm hits the coast", Excerpt: "Thousands of homes lost power overnight", Published: earlier},
+		&Content{ID: "3", Source: "p3", Title: "Local team wins the championship", Excerpt: "Fans celebrated in the streets"},
+		&Content{ID: "4", Source: "p3", Title: "Short"},
+		&Content{ID: "5", Source: "p4", Title: "Short"}}
+
+	groups := findNearDuplicates(content, map[string]int{}, 3, nil)
+	if len(groups) != 1 || len(groups[0]) != 2 {
+		t.Fatalf("Expected one group of 2 items, but got %v", groups)
+	}
+	if groups[0][0].ID != "2" || groups[0][1].ID != "0" {
+		t.Errorf("Expected earliest published content first, but got %v, %v", groups[0][0].ID, groups[0][1].ID)
+	}
+
+	groups = findNearDuplicates(content, map[string]int{"p1": 1}, 3, newFingerprintCache())
+	if len(groups) != 1 || groups[0][0].ID != "0" {
+		t.Errorf("Expected content of provider with highest priority first, but got %v", groups)
+	}
+
+	groups = findNearDuplicates(content[:2], map[string]int{}, 3, nil)
+	if len(groups) != 0 {
+		t.Errorf("Expected content of the same provider not to be grouped, but got %v", groups)
+	}
+}
+
+func TestFindNearDuplicatesGroupsOneItemPerProvider(t *testing.T) {
+	a1 := &Content{ID: "a1", Source: "a", Title: "t"}
+	b := &Content{ID: "b", Source: "b", Title: "t"}
+	a2 := &Content{ID: "a2", Source: "a", Title: "t"}
+	c := &Content{ID: "c", Source: "c", Title: "t"}
+	cache := newFingerprintCache()
+	// a1 ~ b ~ a2 ~ c, with fingerprints differing in one bit from their neighbours
+	cache.fingerprints = map[*Content]uint64{a1: 0x0, b: 0x1, a2: 0x3, c: 0x7}
+	cache.valid = map[*Content]bool{a1: true, b: true, a2: true, c: true}
+
+	groups := findNearDuplicates([]*Content{a1, b, a2, c}, map[string]int{}, 1, cache)
+	for _, group := range groups {
+		sources := make(map[string]bool)
+		for _, c := range group {
+			if sources[c.Source] {
+				t.Errorf("Expected at most one item per provider in group, but got %v", group)
+			}
+			sources[c.Source] = true
+		}
+	}
+	if len(groups) != 2 || len(groups[0])+len(groups[1]) != 4 {
+		t.Errorf("Expected items of the same provider in separate groups, but got %v", groups)
+	}
+}
@@ -3,6 +3,7 @@ package content
 import (
 	"log"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
@@ -14,7 +15,18 @@ import (
 	"github.com/nu7hatch/gouuid"
 )
 
-// Index responsible for indexing content
+// Validators holds the HTTP cache validators returned with a provider's content
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// Number of content items written to the full-text index at once
+const fullTextBatchSize = 1000
+
+// Index responsible for indexing content. Every ingestion creates a new index
+// (read view) which shares the full-text indexes of the previous one, so that only
+// changed content needs to be indexed (see fullTextIndexes).
 type Index struct {
 	id                   string
 	allContent           []*Content
@@ -22,14 +34,65 @@ type Index struct {
 	localizedContent     map[string][]*Content
 	providers            map[string][]*Content
 	providersLastUpdated map[string]time.Time
+	providersValidators  map[string]Validators
+	providersStatus      map[string]ProviderStatus
+	providersHubs        map[string]Hub
+	providersPriority    map[string]int
+	alternates           map[string][]*Content
+	alternateIDs         map[string]bool
 	languages            map[string][]*Content
 	regions              map[string][]*Content
 	scripts              map[string][]*Content
 	tags                 map[string][]*Content
 	fullText             bleve.Index
+	fullTextID           string
+	fullTexts            *fullTextIndexes
+	fullTextBuffer       int
+	store                *Store
+	reports              *Reports
+	fingerprints         *fingerprintCache
+	politeness           *politeness
+	terms                *termStatistics
+	termsOnce            sync.Once
 	mux                  sync.Mutex
 }
 
+// fullTextIndexes holds the two full-text indexes shared by all views of an
+// index. Each view reads one of them, while the next view is written to the
+// other, so that the queries of a view are never affected by the creation of
+// the next view. The index read by a view is only written to once it was
+// replaced by the next view.
+type fullTextIndexes struct {
+	indexes [2]bleve.Index
+	// Content items written to each index
+	indexed [2]map[string]*Content
+}
+
+// get returns the full-text index of the given buffer, creating it if needed
+func (f *fullTextIndexes) get(c Config, id string, buffer int) bleve.Index {
+	if f.indexes[buffer] == nil {
+		file := c.GetFullTextIndexFile()
+		if buffer > 0 {
+			file += "." + strconv.Itoa(buffer)
+		}
+		f.indexes[buffer] = openFullTextIndex(filepath.FromSlash(c.GetFullTextIndexDir() + "/" + id + "/" + file))
+		f.indexed[buffer] = make(map[string]*Content)
+	}
+	return f.indexes[buffer]
+}
+
+func openFullTextIndex(indexPath string) bleve.Index {
+	fullTextIndex, err := bleve.Open(indexPath)
+	if err != nil {
+		mapping := bleve.NewIndexMapping()
+		fullTextIndex, err = bleve.New(indexPath, mapping)
+		if err != nil {
+			log.Fatal("Failed to create index: ", err)
+		}
+	}
+	return fullTextIndex
+}
+
 // CreateIndex creates an index instance, using the provided file name and root directory
 func CreateIndex(c Config) *Index {
 	u, err := uuid.NewV4()
@@ -38,16 +101,10 @@ func CreateIndex(c Config) *Index {
 	}
 
 	var fullTextIndex bleve.Index
+	var fullTexts *fullTextIndexes
 	if c.FullTextIndexActive() {
-		indexPath := filepath.FromSlash(c.GetFullTextIndexDir() + "/" + u.String() + "/" + c.GetFullTextIndexFile())
-		fullTextIndex, err = bleve.Open(indexPath)
-		if err != nil {
-			mapping := bleve.NewIndexMapping()
-			fullTextIndex, err = bleve.New(indexPath, mapping)
-			if err != nil {
-				log.Fatal("Failed to create index: ", err)
-			}
-		}
+		fullTexts = &fullTextIndexes{}
+		fullTextIndex = fullTexts.get(c, u.String(), 0)
 	}
 
 	return &Index{
@@ -57,11 +114,87 @@ func CreateIndex(c Config) *Index {
 		localizedContent:     make(map[string][]*Content),
 		providers:            make(map[string][]*Content),
 		providersLastUpdated: make(map[string]time.Time),
+		providersValidators:  make(map[string]Validators),
+		providersStatus:      make(map[string]ProviderStatus),
+		providersHubs:        make(map[string]Hub),
+		providersPriority:    make(map[string]int),
+		alternates:           make(map[string][]*Content),
+		alternateIDs:         make(map[string]bool),
 		languages:            make(map[string][]*Content),
 		regions:              make(map[string][]*Content),
 		scripts:              make(map[string][]*Content),
 		tags:                 make(map[string][]*Content),
-		fullText:             fullTextIndex}
+		fullText:             fullTextIndex,
+		fullTextID:           u.String(),
+		fullTexts:            fullTexts,
+		fingerprints:         newFingerprintCache(),
+		reports:              NewReports(c.GetIngestReportLimit())}
+}
+
+// createView creates an empty index with a new ID, sharing the full-text indexes,
+// store and ingestion reports of the provided index. The view reads the full-text
+// index not read by the provided index (see updateFullText).
+func createView(c Config, curIndex *Index) *Index {
+	reports := curIndex.reports
+	if reports == nil {
+		reports = NewReports(c.GetIngestReportLimit())
+	}
+
+	if curIndex.fullText == nil && c.FullTextIndexActive() {
+		index := CreateIndex(c)
+		index.store = curIndex.store
+		index.reports = reports
+		index.politeness = curIndex.getPoliteness()
+		return index
+	}
+
+	u, err := uuid.NewV4()
+	if err != nil {
+		log.Fatal("Failed to create index:", err)
+	}
+	index := createIndexWithID(u.String())
+	index.fullTextID = curIndex.fullTextID
+	if curIndex.fullTexts != nil {
+		index.fullTexts = curIndex.fullTexts
+		index.fullTextBuffer = 1 - curIndex.fullTextBuffer
+		index.fullText = index.fullTexts.get(c, index.fullTextID, index.fullTextBuffer)
+	}
+	index.store = curIndex.store
+	index.reports = reports
+	index.fingerprints = curIndex.fingerprints
+	if index.fingerprints == nil {
+		index.fingerprints = newFingerprintCache()
+	}
+	index.politeness = curIndex.getPoliteness()
+	return index
+}
+
+// shareView creates a view with the ID, content and full-text index of the provided
+// index, so that only the per-provider state (see copyProviderState) of the view
+// can be updated without indexing content again
+func shareView(curIndex *Index) *Index {
+	index := createIndexWithID(curIndex.id)
+	index.copyProviderState(curIndex)
+	index.allContent = curIndex.allContent
+	index.content = curIndex.content
+	index.localizedContent = curIndex.localizedContent
+	index.providers = curIndex.providers
+	index.alternates = curIndex.alternates
+	index.alternateIDs = curIndex.alternateIDs
+	index.languages = curIndex.languages
+	index.regions = curIndex.regions
+	index.scripts = curIndex.scripts
+	index.tags = curIndex.tags
+	index.fullText = curIndex.fullText
+	index.fullTextID = curIndex.fullTextID
+	index.fullTexts = curIndex.fullTexts
+	index.fullTextBuffer = curIndex.fullTextBuffer
+	index.store = curIndex.store
+	index.reports = curIndex.reports
+	index.fingerprints = curIndex.fingerprints
+	index.politeness = curIndex.getPoliteness()
+	index.termsOnce.Do(func() { index.terms = curIndex.getTermStatistics() })
+	return index
 }
 
 // createIndexWithID creates and empty index with the provided ID
@@ -73,6 +206,12 @@ func createIndexWithID(id string) *Index {
 		localizedContent:     make(map[string][]*Content),
 		providers:            make(map[string][]*Content),
 		providersLastUpdated: make(map[string]time.Time),
+		providersValidators:  make(map[string]Validators),
+		providersStatus:      make(map[string]ProviderStatus),
+		providersHubs:        make(map[string]Hub),
+		providersPriority:    make(map[string]int),
+		alternates:           make(map[string][]*Content),
+		alternateIDs:         make(map[string]bool),
 		languages:            make(map[string][]*Content),
 		regions:              make(map[string][]*Content),
 		scripts:              make(map[string][]*Content),
@@ -80,28 +219,89 @@ func createIndexWithID(id string) *Index {
 		fullText:             nil}
 }
 
-// Add adds the provided content items to this index
+// Add adds the provided content items to this index, writing them to the
+// full-text index in batches
 func (i *Index) Add(c []*Content) error {
 	i.mux.Lock()
 	defer i.mux.Unlock()
 
 	for _, content := range c {
-		err := i.AddItem(content)
+		i.addItem(content)
+	}
+	if i.fullText == nil {
+		return nil
+	}
+
+	batch := i.fullText.NewBatch()
+	for _, content := range c {
+		err := batch.Index(content.ID, fullTextOf(content))
 		if err != nil {
 			return err
 		}
+		if batch.Size() >= fullTextBatchSize {
+			err = i.fullText.Batch(batch)
+			if err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	err := i.fullText.Batch(batch)
+	if err != nil {
+		return err
+	}
+	for _, content := range c {
+		i.setIndexed(content)
 	}
 	return nil
 }
 
 // AddItem adds a content item to this index. This method is not thread-safe.
 func (i *Index) AddItem(c *Content) error {
+	i.addItem(c)
+
+	// Add to full-text index
+	if i.fullText != nil {
+		err := i.fullText.Index(c.ID, fullTextOf(c))
+		if err != nil {
+			return err
+		}
+		i.setIndexed(c)
+	}
+
+	return nil
+}
+
+// setIndexed records that the content item was written to the full-text index of this view
+func (i *Index) setIndexed(c *Content) {
+	if i.fullTexts != nil {
+		i.fullTexts.indexed[i.fullTextBuffer][c.ID] = c
+	}
+}
+
+// addToView adds the provided content items to this index, without writing them
+// to the full-text index (see updateFullText)
+func (i *Index) addToView(c []*Content) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	for _, content := range c {
+		i.addItem(content)
+	}
+}
+
+func (i *Index) addItem(c *Content) {
 	i.allContent = append(i.allContent, c)
 	i.content[c.ID] = c
 
 	// Index provider
 	i.providers[c.Source] = append(i.providers[c.Source], c)
 
+	i.indexItem(c)
+}
+
+// indexItem adds the content item to the tag and locale indexes
+func (i *Index) indexItem(c *Content) {
 	// Index tags
 	for _, tag := range c.Tags {
 		lTag := strings.ToLower(tag)
@@ -118,13 +318,111 @@ func (i *Index) AddItem(c *Content) error {
 	}
 	indexLocaleValue(c.Language, c, i.languages)
 	indexLocaleValue(c.Script, c, i.scripts)
+}
 
-	// Add to full-text index
-	if i.fullText != nil {
-		return i.fullText.Index(c.ID, c.Title+" "+c.Excerpt)
+// updateFullText brings the full-text index read by this view in line with its
+// content. The index was last written for the view preceding the current one, so
+// only content which is new or changed since then is indexed, and content no
+// longer present is removed. Content items reused from that view are skipped
+// without comparing their full text. The full-text index read by the current view isn't
+// changed, so its queries are unaffected until this view replaces it.
+func (i *Index) updateFullText() (int, int, error) {
+	if i.fullText == nil || i.fullTexts == nil {
+		return 0, 0, nil
 	}
 
-	return nil
+	written := i.fullTexts.indexed[i.fullTextBuffer]
+	pending := make(map[string]*Content)
+	indexed, removed := 0, 0
+	batch := i.fullText.NewBatch()
+	flush := func(force bool) error {
+		if batch.Size() == 0 || (!force && batch.Size() < fullTextBatchSize) {
+			return nil
+		}
+		err := i.fullText.Batch(batch)
+		batch.Reset()
+		if err == nil {
+			for id, c := range pending {
+				if c == nil {
+					delete(written, id)
+				} else {
+					written[id] = c
+				}
+			}
+		}
+		pending = make(map[string]*Content)
+		return err
+	}
+
+	for id, c := range i.content {
+		if w, ok := written[id]; ok && (w == c || fullTextOf(w) == fullTextOf(c)) {
+			written[id] = c
+			continue
+		}
+		err := batch.Index(id, fullTextOf(c))
+		if err != nil {
+			return indexed, removed, err
+		}
+		pending[id] = c
+		indexed++
+		if err = flush(false); err != nil {
+			return indexed, removed, err
+		}
+	}
+
+	for id := range written {
+		if _, ok := i.content[id]; ok {
+			continue
+		}
+		batch.Delete(id)
+		pending[id] = nil
+		removed++
+		if err := flush(false); err != nil {
+			return indexed, removed, err
+		}
+	}
+	return indexed, removed, flush(true)
+}
+
+// groupDuplicates groups near-duplicate content of different providers (see
+// findNearDuplicates). Only the canonical item of each group is recommended, the
+// others are removed from all but the provider's content and made available as
+// its alternates (see GetAlternates). Negative distances disable grouping.
+func (i *Index) groupDuplicates(maxDistance int) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	if maxDistance < 0 {
+		return
+	}
+
+	groups := findNearDuplicates(i.allContent, i.providersPriority, maxDistance, i.fingerprints)
+	if len(groups) == 0 {
+		return
+	}
+	for _, group := range groups {
+		i.alternates[group[0].ID] = group[1:]
+		for _, c := range group[1:] {
+			i.alternateIDs[c.ID] = true
+		}
+	}
+
+	allContent := i.allContent
+	i.allContent = make([]*Content, 0, len(allContent))
+	i.languages = make(map[string][]*Content)
+	i.regions = make(map[string][]*Content)
+	i.scripts = make(map[string][]*Content)
+	i.tags = make(map[string][]*Content)
+	for _, c := range allContent {
+		if !i.alternateIDs[c.ID] {
+			i.allContent = append(i.allContent, c)
+			i.indexItem(c)
+		}
+	}
+	log.Printf("Grouped %v near-duplicate items into %v groups", len(i.alternateIDs), len(groups))
+}
+
+func fullTextOf(c *Content) string {
+	return c.Title + " " + c.Excerpt
 }
 
 // Query index for content
@@ -140,7 +438,7 @@ func (i *Index) Query(q string) ([]*Content, error) {
 	if searchResult != nil {
 		for _, hit := range searchResult.Hits {
 			hitc := i.content[hit.ID]
-			if hitc != nil {
+			if hitc != nil && !i.alternateIDs[hit.ID] {
 				c = append(c, hitc)
 			}
 		}
@@ -158,6 +456,12 @@ func (i *Index) GetContent() []*Content {
 	return i.allContent
 }
 
+// GetAlternates returns the near-duplicates of the given content from other
+// providers, which aren't recommended themselves
+func (i *Index) GetAlternates(id string) []*Content {
+	return i.alternates[id]
+}
+
 // GetLocalizedContent returns indexed content matching the provided language, script and regions
 func (i *Index) GetLocalizedContent(acceptLang string) []*Content {
 	if i.localizedContent[acceptLang] != nil {
@@ -217,6 +521,124 @@ func (i *Index) SetProviderLastUpdated(provider string) {
 	i.providersLastUpdated[provider] = time.Now()
 }
 
+// GetProviderValidators returns the HTTP cache validators of the given provider's content
+func (i *Index) GetProviderValidators(provider string) Validators {
+	return i.providersValidators[provider]
+}
+
+// SetProviderValidators sets the HTTP cache validators of the given provider's content
+func (i *Index) SetProviderValidators(provider string, v Validators) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	i.providersValidators[provider] = v
+}
+
+// GetProviderStatus returns the failure status of the given provider
+func (i *Index) GetProviderStatus(provider string) ProviderStatus {
+	return i.providersStatus[provider]
+}
+
+// SetProviderStatus sets the failure status of the given provider
+func (i *Index) SetProviderStatus(provider string, s ProviderStatus) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	i.providersStatus[provider] = s
+}
+
+// GetProviderHub returns the WebSub hub advertised by the given provider, if any
+func (i *Index) GetProviderHub(provider string) Hub {
+	return i.providersHubs[provider]
+}
+
+// SetProviderHub sets the WebSub hub advertised by the given provider
+func (i *Index) SetProviderHub(provider string, h Hub) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	i.providersHubs[provider] = h
+}
+
+// setProviderPriority sets the priority of the given provider's content over near-duplicates
+func (i *Index) setProviderPriority(provider string, priority int) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	i.providersPriority[provider] = priority
+}
+
+// setProviderState sets the state of the given provider after an attempt to refresh
+// its content. The time of a refresh is persisted, if the index has a store.
+func (i *Index) setProviderState(provider string, s providerState) {
+	i.mux.Lock()
+	i.providersValidators[provider] = s.validators
+	i.providersStatus[provider] = s.status
+	i.providersHubs[provider] = s.hub
+	updated := time.Now()
+	if s.refreshed {
+		i.providersLastUpdated[provider] = updated
+	}
+	if s.report != nil {
+		i.reports.Add(*s.report)
+	}
+	i.mux.Unlock()
+
+	if s.refreshed && i.store != nil {
+		err := i.store.SaveLastUpdated(provider, updated)
+		if err != nil {
+			log.Printf("Failed to persist last update of provider %v: %v", provider, err)
+		}
+	}
+}
+
+// GetReports returns the most recent ingestion reports of the provider, or of
+// all providers if empty
+func (i *Index) GetReports(provider string) []Report {
+	return i.reports.Get(provider)
+}
+
+// copyProviderState copies the per-provider state (last update, cache validators,
+// status, hub and priority) of the provided index
+func (i *Index) copyProviderState(from *Index) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	for k, v := range from.providersLastUpdated {
+		i.providersLastUpdated[k] = v
+	}
+	for k, v := range from.providersValidators {
+		i.providersValidators[k] = v
+	}
+	for k, v := range from.providersStatus {
+		i.providersStatus[k] = v
+	}
+	for k, v := range from.providersHubs {
+		i.providersHubs[k] = v
+	}
+	for k, v := range from.providersPriority {
+		i.providersPriority[k] = v
+	}
+}
+
+// getTermStatistics returns the term statistics of all indexed content, computed
+// on first use
+func (i *Index) getTermStatistics() *termStatistics {
+	i.termsOnce.Do(func() {
+		i.terms = newTermStatistics()
+		for _, c := range i.allContent {
+			i.terms.add(c)
+		}
+	})
+	return i.terms
+}
+
+// getPoliteness returns the state of requests to content hosts, shared by all views
+// of this index, creating it on first use
+func (i *Index) getPoliteness() *politeness {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	if i.politeness == nil {
+		i.politeness = newPoliteness()
+	}
+	return i.politeness
+}
+
 // GetProviderContent returns all indexed content from the given provider
 func (i *Index) GetProviderContent(provider string) []*Content {
 	return i.providers[provider]
@@ -131,3 +131,103 @@ func TestGetTaggedContent(t *testing.T) {
 		t.Error("Received invalid content for provided tag")
 	}
 }
+
+func TestUpdateFullTextOnlyIndexesChanges(t *testing.T) {
+	config := &TestConfig{}
+	prev := CreateIndex(config)
+	err := prev.Add([]*Content{
+		&Content{ID: "0", Title: "unchanged"},
+		&Content{ID: "1", Title: "changed"},
+		&Content{ID: "2", Title: "removed"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first view is written to a full-text index of its own
+	view := createView(config, prev)
+	if view.GetID() == prev.GetID() || view.fullTextID != prev.fullTextID || view.fullText == prev.fullText {
+		t.Fatal("Expected new view with new ID and full-text index in the same directory")
+	}
+	view.addToView([]*Content{
+		&Content{ID: "0", Title: "unchanged"},
+		&Content{ID: "1", Title: "changed"},
+		&Content{ID: "2", Title: "removed"}})
+	indexed, removed, err := view.updateFullText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indexed != 3 || removed != 0 {
+		t.Errorf("Expected 3 items indexed and 0 removed, but got %v and %v", indexed, removed)
+	}
+
+	// The next view reuses the full-text index of prev, which is no longer served
+	index := createView(config, view)
+	if index.fullText != prev.fullText {
+		t.Fatal("Expected view to reuse the full-text index of the previous but one view")
+	}
+	index.addToView([]*Content{
+		&Content{ID: "0", Title: "unchanged"},
+		&Content{ID: "1", Title: "updated"},
+		&Content{ID: "3", Title: "added"}})
+
+	indexed, removed, err = index.updateFullText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indexed != 2 || removed != 1 {
+		t.Errorf("Expected 2 items indexed and 1 removed, but got %v and %v", indexed, removed)
+	}
+
+	for q, want := range map[string]int{"unchanged": 1, "changed": 0, "updated": 1, "removed": 0, "added": 1} {
+		hits, err := index.Query(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) != want {
+			t.Errorf("Expected %v hits for %v, but got %v", want, q, len(hits))
+		}
+	}
+
+	// Queries of the replaced view are unaffected
+	for q, want := range map[string]int{"changed": 1, "removed": 1, "updated": 0, "added": 0} {
+		hits, err := view.Query(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) != want {
+			t.Errorf("Expected %v hits for %v in the replaced view, but got %v", want, q, len(hits))
+		}
+	}
+}
+
+func TestGroupDuplicates(t *testing.T) {
+	index := CreateIndex(&TestConfig{})
+	index.setProviderPriority("wire", 1)
+	err := index.Add([]*Content{
+		&Content{ID: "0", Source: "p1", Tags: []string{"t1"}, Title: "Storm hits the coast", Excerpt: "Thousands of homes lost power overnight"},
+		&Content{ID: "1", Source: "wire", Tags: []string{"t1"}, Title: "Storm Hits the Coast!", Excerpt: "Thousands of homes lost power overnight."},
+		&Content{ID: "2", Source: "p1", Tags: []string{"t1"}, Title: "Local team wins the championship", Excerpt: "Fans celebrated in the streets"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.groupDuplicates(3)
+
+	if len(index.GetContent()) != 2 || len(index.GetTaggedContent("t1")) != 2 {
+		t.Errorf("Expected alternates to be removed, but got %v", index.GetContent())
+	}
+	if len(index.GetProviderContent("p1")) != 2 {
+		t.Errorf("Expected provider content to be retained, but got %v", index.GetProviderContent("p1"))
+	}
+	alternates := index.GetAlternates("1")
+	if len(alternates) != 1 || alternates[0].ID != "0" {
+		t.Errorf("Expected alternate 0 of content of provider with highest priority, but got %v", alternates)
+	}
+
+	hits, err := index.Query("storm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].ID != "1" {
+		t.Errorf("Expected only canonical content to be found, but got %v", hits)
+	}
+}
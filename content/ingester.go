@@ -1,9 +1,14 @@
 package content
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 
@@ -11,9 +16,11 @@ import (
 
 	"github.com/jaytaylor/html2text"
 	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
 
 	"log"
 
+	"net"
 	"net/http"
 	"time"
 
@@ -22,70 +29,215 @@ import (
 	"mozilla.org/crec/content/processor"
 )
 
+// errNotModified indicates that a provider's content hasn't changed since it was last fetched
+var errNotModified = errors.New("Content not modified")
+
+// statusError indicates an unsuccessful HTTP response
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "Unexpected response status: " + e.status
+}
+
 // Ingest content from configured providers
 func Ingest(config Config, providers Providers, curIndex *Index) *Index {
 	cleanUp(config, curIndex)
 
-	index := CreateIndex(config)
+	index := createView(config, curIndex)
 
+	// Providers are refreshed by a bounded number of workers
+	workers := config.GetProviderConcurrency()
+	if workers <= 0 || workers > len(providers) {
+		workers = len(providers)
+	}
+	queue := make(chan *Provider)
 	var wg sync.WaitGroup
-	wg.Add(len(providers))
-	for _, p := range providers {
-		go func(provider *Provider) {
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
 			defer wg.Done()
-			var err error
-
-			if provider.ContentURL != "" {
-				lastUpdated := curIndex.GetProviderLastUpdated(provider.ID)
-				nextRefresh := config.GetIndexRefreshInterval()
-
-				if int(time.Now().Add(nextRefresh).Sub(lastUpdated).Minutes()) > provider.MaxContentAge {
-					log.Println("Refreshing content from provider " + provider.ID)
-					err = ingestFromProvider(provider, index)
-					if err == nil {
-						index.SetProviderLastUpdated(provider.ID)
-					}
-				} else {
-					log.Println("Reusing content from provider " + provider.ID)
-					index.Add(curIndex.GetProviderContent(provider.ID))
+			for provider := range queue {
+				content := make([]*Content, 0)
+
+				if provider.ContentURL != "" {
+					lastUpdated := curIndex.GetProviderLastUpdated(provider.ID)
+					nextRefresh := config.GetIndexRefreshInterval()
+					due := int(time.Now().Add(nextRefresh).Sub(lastUpdated).Minutes()) > provider.MaxContentAge
+
+					state := refreshProvider(config, provider, curIndex, due)
+					index.setProviderState(provider.ID, state)
+					content = state.content
 				}
-			} else {
-				err = ingestFromQueue(config, provider, index)
-			}
 
-			if err != nil {
-				index.Add(curIndex.GetProviderContent(provider.ID))
-				log.Printf("Failed to refresh content from provider %v: %v", provider.ID, err)
+				addProviderContent(config, provider, content, index, curIndex)
 			}
-		}(p)
+		}()
 	}
+	for _, p := range providers {
+		queue <- p
+	}
+	close(queue)
 	wg.Wait()
 
+	indexed, removed, err := index.updateFullText()
+	if err != nil {
+		log.Println("Failed to update full-text index: ", err)
+	} else {
+		log.Printf("Updated full-text index (%v items indexed, %v removed)", indexed, removed)
+	}
+
+	index.groupDuplicates(config.GetNearDuplicateMaxDistance())
 	index.PreLoadLocales(config.GetLocales())
 	log.Println("Indexing complete")
 	return index
 }
 
-// Enqueue writes content to the disc to be ingested in the next indexing iteration
-func Enqueue(config Config, content []byte, provider string) error {
-	path := filepath.Join(config.GetImportQueueDir(), provider)
-	err := os.MkdirAll(path, os.ModePerm)
+// providerState holds the outcome of an attempt to refresh a provider's content
+type providerState struct {
+	// Refreshed content, or the content of the current index if not refreshed
+	content    []*Content
+	validators Validators
+	status     ProviderStatus
+	hub        Hub
+	refreshed  bool
+	// Report of the attempt to fetch content, nil if it wasn't fetched
+	report *Report
+}
+
+// refreshProvider fetches the provider's content from its ContentURL if it is due,
+// unless the provider is suspended. The provider's content and state of the current
+// index are retained if the content isn't refreshed.
+func refreshProvider(config Config, provider *Provider, curIndex *Index, due bool) providerState {
+	state := providerState{
+		content:    curIndex.GetProviderContent(provider.ID),
+		validators: curIndex.GetProviderValidators(provider.ID),
+		status:     curIndex.GetProviderStatus(provider.ID),
+		hub:        curIndex.GetProviderHub(provider.ID)}
+
+	if state.status.Tripped() {
+		log.Printf("Reusing content from suspended provider %v until %v",
+			provider.ID, state.status.TrippedUntil.Format(time.RFC3339))
+		return state
+	}
+	if !due {
+		log.Println("Reusing content from provider " + provider.ID)
+		return state
+	}
+
+	log.Println("Refreshing content from provider " + provider.ID)
+	report := newReport(provider, reportTypeFetch)
+	content, validators, hub, err := ingestFromProvider(config, provider, curIndex, report)
+	report.finish(content, err)
 	if err != nil {
-		return err
+		state.status = recordFailure(config, provider, state.status, err)
+		state.report = report
+		log.Printf("Failed to refresh content from provider %v: %v", provider.ID, err)
+		return state
+	}
+	if report.rejections() > 0 {
+		log.Printf("Skipped %v items from provider %v", report.rejections(), provider.ID)
+	}
+	return providerState{content: content, validators: validators, hub: hub, refreshed: true, report: report}
+}
+
+// addProviderContent merges the provider's queued content into the provided content
+// (see providerContent), and adds the result to the index.
+func addProviderContent(config Config, provider *Provider, content []*Content, index *Index, curIndex *Index) {
+	addToView(provider, providerContent(config, provider, content, curIndex), index)
+}
+
+// providerContent merges the provider's queued content into the provided content and
+// drops items exceeding the provider's maximum age. The provider's content of the
+// current index is used if the queue can't be read.
+func providerContent(config Config, provider *Provider, content []*Content, curIndex *Index) []*Content {
+	queued, err := ingestFromQueue(config, provider, content, curIndex)
+	if err == nil {
+		content = queued
+	} else {
+		content = curIndex.GetProviderContent(provider.ID)
+		log.Printf("Failed to refresh queued content from provider %v: %v", provider.ID, err)
+	}
+
+	if maxAge := provider.GetMaxItemAge(); maxAge > 0 {
+		content = Filter(content, func(c *Content) bool {
+			return c.Published == nil || time.Since(c.Published.Time) <= maxAge
+		})
+	}
+	return content
+}
+
+// addToView persists the provider's content and adds it to the index, along with
+// the provider's priority
+func addToView(provider *Provider, content []*Content, index *Index) {
+	if index.store != nil {
+		err := index.store.Save(provider.ID, content, time.Now())
+		if err != nil {
+			log.Printf("Failed to persist content from provider %v: %v", provider.ID, err)
+		}
+	}
+	index.setProviderPriority(provider.ID, provider.Priority)
+	index.addToView(content)
+}
+
+// updateProviderContent returns a new view of the current index in which the given
+// provider's content is replaced by the provided content, merged with its queued
+// content, and the provider's state is updated, if provided. If the provider's
+// content didn't change, the current index is returned as is, or a view sharing
+// its content if the provider's state changed. Otherwise, content of other
+// providers is reused, so only the provider's content needs to be indexed.
+func updateProviderContent(config Config, provider *Provider, content []*Content, state *providerState, curIndex *Index) *Index {
+	content = providerContent(config, provider, content, curIndex)
+	if sameContent(content, curIndex.GetProviderContent(provider.ID)) {
+		if state == nil || (!state.refreshed && state.report == nil) {
+			return curIndex
+		}
+		index := shareView(curIndex)
+		index.setProviderState(provider.ID, *state)
+		return index
+	}
+
+	index := createView(config, curIndex)
+	index.copyProviderState(curIndex)
+	for id, c := range curIndex.providers {
+		if id != provider.ID {
+			index.addToView(c)
+		}
+	}
+	addToView(provider, content, index)
+	if state != nil {
+		index.setProviderState(provider.ID, *state)
 	}
-	f, err := ioutil.TempFile(path, "import")
+
+	_, _, err := index.updateFullText()
 	if err != nil {
-		return err
+		log.Println("Failed to update full-text index: ", err)
+	}
+	index.groupDuplicates(config.GetNearDuplicateMaxDistance())
+	index.PreLoadLocales(config.GetLocales())
+	return index
+}
+
+// sameContent returns true if both lists hold equal content in the same order
+func sameContent(a []*Content, b []*Content) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] && !reflect.DeepEqual(a[i], b[i]) {
+			return false
+		}
 	}
-	_, err = f.Write(content)
-	return err
+	return true
 }
 
-// cleanUp deletes all but the current active index
+// cleanUp deletes all but the current active full-text index
 func cleanUp(config Config, curIndex *Index) {
 	indexDirs, _ := ioutil.ReadDir(config.GetFullTextIndexDir())
 	for _, indexDir := range indexDirs {
-		if indexDir.Name() != curIndex.GetID() {
+		if indexDir.Name() != curIndex.fullTextID {
 			err := os.RemoveAll(filepath.FromSlash(config.GetFullTextIndexDir() + "/" + indexDir.Name()))
 			if err != nil {
 				log.Println("Failed to clean up old indexes: ", err)
@@ -94,107 +246,244 @@ func cleanUp(config Config, curIndex *Index) {
 	}
 }
 
-func ingestFromProvider(provider *Provider, index *Index) error {
-	client := &http.Client{Timeout: time.Duration(time.Second * 5)}
+// recordFailure updates the provider's status after a failed attempt to fetch content,
+// suspending further attempts once the configured failure threshold is reached.
+func recordFailure(config Config, provider *Provider, status ProviderStatus, err error) ProviderStatus {
+	status.ConsecutiveFailures++
+	status.LastError = err.Error()
+	status.LastFailure = time.Now()
+
+	threshold := config.GetProviderFailureThreshold()
+	if threshold > 0 && status.ConsecutiveFailures >= threshold {
+		status.TrippedUntil = status.LastFailure.Add(config.GetProviderCoolDown())
+		log.Printf("Suspending provider %v until %v after %v consecutive failures",
+			provider.ID, status.TrippedUntil.Format(time.RFC3339), status.ConsecutiveFailures)
+	}
+	return status
+}
+
+// ingestFromProvider fetches the provider's content from its ContentURL, retrying
+// transient failures. Requests are spaced out per host and, if enabled, only sent
+// if permitted by the host's robots.txt. The outcome is recorded in the report.
+// If the content wasn't modified since it was last fetched, the content of the
+// current index is returned.
+func ingestFromProvider(config Config, provider *Provider, curIndex *Index, report *Report) ([]*Content, Validators, Hub, error) {
+	polite := curIndex.getPoliteness()
+	client := &http.Client{Timeout: provider.GetTimeout()}
+	backoff := config.GetProviderRetryBackoff()
+
+	attempt := func() ([]*Content, Validators, Hub, error) {
+		err := polite.awaitHost(config, provider, client)
+		if err != nil {
+			return nil, Validators{}, Hub{}, err
+		}
+		return ingestFromURL(provider, client, newPageFetcher(config, provider, client, polite, report), curIndex, report)
+	}
+
+	content, validators, hub, err := attempt()
+	for retry := 0; retry < config.GetProviderMaxRetries() && isTransient(err); retry++ {
+		log.Printf("Retrying provider %v in %v: %v", provider.ID, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+		content, validators, hub, err = attempt()
+	}
+
+	if err == errNotModified {
+		log.Println("Content not modified, reusing content from provider " + provider.ID)
+		return curIndex.GetProviderContent(provider.ID),
+			curIndex.GetProviderValidators(provider.ID),
+			curIndex.GetProviderHub(provider.ID), nil
+	}
+	if err == nil && provider.EnrichMetadata {
+		content = enrichContent(config, provider, client, polite, content)
+	}
+	if err == nil {
+		analyzeContent(config, provider, curIndex, content)
+	}
+	return content, validators, hub, err
+}
+
+// analyzeContent detects the language of the content and extracts tags from
+// content without tags of its own (see detectLanguage and extractTags)
+func analyzeContent(config Config, provider *Provider, curIndex *Index, content []*Content) {
+	for _, c := range content {
+		detectLanguage(c)
+	}
+	extractTags(config.GetMaxGeneratedTags(), provider, curIndex, content)
+}
+
+func ingestFromURL(provider *Provider, client *http.Client, pages *pageFetcher, curIndex *Index, report *Report) ([]*Content, Validators, Hub, error) {
+	body, validators, hub, err := fetch(provider, client, curIndex, report)
+	if err != nil {
+		return nil, validators, hub, err
+	}
+
+	content, err := parseContent(body, provider, pages, report)
+	return content, validators, hub, err
+}
+
+// parseContent parses content in the provider's format, detecting the format if
+// it isn't declared (see Provider.Format). URLs of the content are canonicalized.
+func parseContent(body []byte, provider *Provider, pages *pageFetcher, report *Report) ([]*Content, error) {
+	format := provider.GetFormat()
+	if format == "" {
+		format = detectFormat(body)
+	}
+
+	var content []*Content
 	var err error
-	if provider.Native {
-		err = ingestNative(provider, client, index)
-	} else {
-		err = ingestSyndicationFeed(provider, client, index)
+	switch format {
+	case FormatNative:
+		content, err = parseJSON(body, provider)
+	case FormatJSONFeed:
+		content, err = parseJSONFeed(body, provider, pages, report)
+	case FormatJSON:
+		content, err = parseMappedJSON(body, provider, pages, report)
+	default:
+		content, err = parseFeed(body, provider, pages, report)
 	}
-	return err
+	if err != nil {
+		return nil, err
+	}
+	return canonicalizeContent(provider, content), nil
 }
 
-func ingestFromQueue(config Config, provider *Provider, index *Index) error {
-	path := filepath.Join(config.GetImportQueueDir(), provider.ID)
-	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
-		if info != nil && !info.IsDir() {
-			bytes, err := ioutil.ReadFile(path)
-			if err != nil {
-				return err
-			}
+// detectFormat determines the format of content based on its first characters:
+// JSON arrays hold content in our format, JSON objects JSON Feeds, everything
+// else is expected to be an RSS or Atom feed.
+func detectFormat(body []byte) string {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")))
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return FormatNative
+	}
+	if isJSONFeed(trimmed) {
+		return FormatJSONFeed
+	}
+	return FormatFeed
+}
 
-			err = ingestJSON(bytes, provider, index)
-			if err != nil {
-				return err
-			}
-		}
-		return nil
-	})
-	return err
+// isTransient returns true if the error is likely to go away when retrying
+// e.g. network and server errors.
+func isTransient(err error) bool {
+	switch e := err.(type) {
+	case *statusError:
+		return e.code >= 500 || e.code == http.StatusTooManyRequests
+	case net.Error:
+		return true
+	}
+	return false
 }
 
-func ingestNative(provider *Provider, client *http.Client, index *Index) error {
-	resp, err := client.Get(provider.ContentURL)
+// fetch retrieves the provider's content along with its cache validators and the
+// WebSub hub it advertises, if any. The cache validators of the current index are
+// sent along so that unchanged content isn't downloaded again, in which case
+// errNotModified is returned. The response status is recorded in the report.
+func fetch(provider *Provider, client *http.Client, curIndex *Index, report *Report) ([]byte, Validators, Hub, error) {
+	req, err := http.NewRequest("GET", provider.ContentURL, nil)
 	if err != nil {
-		return err
+		return nil, Validators{}, Hub{}, err
+	}
+
+	err = provider.prepareRequest(req)
+	if err != nil {
+		return nil, Validators{}, Hub{}, err
+	}
+
+	validators := curIndex.GetProviderValidators(provider.ID)
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, Validators{}, Hub{}, err
 	}
 	defer resp.Body.Close()
+	report.Status = resp.StatusCode
 
-	body, err := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, validators, Hub{}, errNotModified
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, Validators{}, Hub{}, &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+
+	body, err := readBody(provider, resp.Body)
 	if err != nil {
-		return err
+		return nil, Validators{}, Hub{}, err
+	}
+
+	return body, Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified")}, discoverHub(provider, resp.Header, body), nil
+}
+
+// readBody reads the response body, enforcing the provider's maximum content size
+func readBody(provider *Provider, body io.Reader) ([]byte, error) {
+	if provider.MaxContentSize <= 0 {
+		return ioutil.ReadAll(body)
 	}
 
-	return ingestJSON(body, provider, index)
+	data, err := ioutil.ReadAll(io.LimitReader(body, provider.MaxContentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > provider.MaxContentSize {
+		return nil, fmt.Errorf("Content exceeds maximum size of %v bytes", provider.MaxContentSize)
+	}
+	return data, nil
 }
 
-func ingestJSON(bytes []byte, provider *Provider, index *Index) error {
+// parseJSON parses content in our format, applying the provider's defaults.
+// Invalid publication dates are dropped.
+func parseJSON(bytes []byte, provider *Provider) ([]*Content, error) {
 	var content []*Content
 	err := json.Unmarshal(bytes, &content)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, item := range content {
+		if item.Source == "" {
+			item.Source = provider.ID
+		}
+		if item.Published != nil && !item.Published.Valid() {
+			item.Published = nil
+		}
 		if len(item.Domains) == 0 {
 			item.Domains = provider.Domains
 		}
 		item = maybeAppendExplanation(item)
 	}
-
-	index.Add(content)
-
-	return nil
+	return content, nil
 }
 
-func ingestSyndicationFeed(provider *Provider, client *http.Client, index *Index) error {
+// parseFeed parses an RSS or Atom feed, applying the provider's processors. Items
+// which can't be processed are skipped and recorded in the report.
+func parseFeed(body []byte, provider *Provider, pages *pageFetcher, report *Report) ([]*Content, error) {
 	fp := gofeed.NewParser()
-	fp.Client = client
-	feed, err := fp.ParseURL(provider.ContentURL)
+	feed, err := fp.Parse(bytes.NewReader(body))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	content := make([]*Content, 0)
 	for _, item := range feed.Items {
-		newc, err := createContentFromFeedItem(provider, item)
+		newc, err := createContentFromFeedItem(provider, item, pages)
 		if err != nil {
-			return err
+			report.reject(findID(item), err)
+			continue
 		}
 		content = append(content, newc)
 	}
-	index.Add(content)
-
-	return nil
+	return content, nil
 }
 
-func createContentFromFeedItem(provider *Provider, item *gofeed.Item) (*Content, error) {
-	r := strings.NewReader(item.Description)
-	doc, err := html.Parse(r)
-	if err != nil {
-		return nil, err
-	}
-
-	var context = processor.NewHTMLContext(doc)
-	for _, processor := range provider.GetProcessors() {
-		context, err = processor.Process(context)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	summary, err := html2text.FromHtmlNode(context.Content.(*html.Node))
+func createContentFromFeedItem(provider *Provider, item *gofeed.Item, pages *pageFetcher) (*Content, error) {
+	summary, context, err := processHTML(provider, item.Description, item.Link, pages)
 	if err != nil {
 		return nil, err
 	}
@@ -205,11 +494,11 @@ func createContentFromFeedItem(provider *Provider, item *gofeed.Item) (*Content,
 		Title:     item.Title,
 		URL:       item.Link,
 		Image:     findImage(item, context),
-		Excerpt:   summary,
-		HTML:      item.Description,
+		Excerpt:   findExcerpt(summary, context),
+		HTML:      findHTML(item.Description, context),
 		Tags:      append(item.Categories, provider.Categories...),
 		Author:    processAuthor(item),
-		Published: item.Published,
+		Published: findPublished(item),
 		Regions:   provider.Regions,
 		Language:  provider.Language,
 		Script:    provider.Script,
@@ -218,20 +507,110 @@ func createContentFromFeedItem(provider *Provider, item *gofeed.Item) (*Content,
 	return maybeAppendExplanation(newc), nil
 }
 
+// processHTML applies the provider's processors to the HTML content of the item
+// at the given URL, and returns its text along with the processing context.
+// Processors fetch linked pages using the page fetcher, if any.
+func processHTML(provider *Provider, s string, url string, pages *pageFetcher) (string, *processor.Context, error) {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return "", nil, err
+	}
+
+	var context = processor.NewHTMLContext(doc)
+	context.URL = url
+	context.Fetch = pages.fetcher()
+	for _, processor := range provider.GetProcessors() {
+		context, err = processor.Process(context)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	summary, err := html2text.FromHtmlNode(context.Content.(*html.Node))
+	if err != nil {
+		return "", nil, err
+	}
+	return summary, context, nil
+}
+
+// findHTML returns the HTML provided by processors (i.e. the full article), falling
+// back to the item's HTML
+func findHTML(html string, context *processor.Context) string {
+	if context.Result["html"] != "" {
+		return context.Result["html"]
+	}
+	return html
+}
+
+// findExcerpt returns the excerpt provided by processors, falling back to the
+// summary of the item's HTML
+func findExcerpt(summary string, context *processor.Context) string {
+	if context.Result["excerpt"] != "" {
+		return context.Result["excerpt"]
+	}
+	return summary
+}
+
+// findImage returns the item's image, declared as part of the item (image,
+// media:content, media:thumbnail, image enclosures or itunes:image) or found by
+// processors in its content, in that order, preferring images served over HTTPS.
+// Relative URLs are resolved against the item's link.
 func findImage(item *gofeed.Item, context *processor.Context) string {
-	if item.Image != nil && item.Image.URL != "" {
-		return item.Image.URL
+	images := make([]string, 0)
+	add := func(image string) {
+		images = append(images, processor.ResolveImageURL(item.Link, image))
 	}
 
-	contentExt := item.Extensions["media"]["content"]
-	for _, cExt := range contentExt {
-		url := cExt.Attrs["url"]
-		if url != "" {
-			return url
+	if item.Image != nil {
+		add(item.Image.URL)
+	}
+	for _, media := range findMediaExtensions(item, "content") {
+		medium, mediaType := media.Attrs["medium"], media.Attrs["type"]
+		if medium == "image" || strings.HasPrefix(mediaType, "image/") || (medium == "" && mediaType == "") {
+			add(media.Attrs["url"])
 		}
 	}
+	for _, media := range findMediaExtensions(item, "thumbnail") {
+		add(media.Attrs["url"])
+	}
+	for _, enclosure := range item.Enclosures {
+		if strings.HasPrefix(enclosure.Type, "image/") {
+			add(enclosure.URL)
+		}
+	}
+	for _, itunes := range item.Extensions["itunes"]["image"] {
+		add(itunes.Attrs["href"])
+	}
+	images = append(images, context.Result["image"])
 
-	return context.Result["image"]
+	return processor.PreferHTTPS(images...)
+}
+
+// findMediaExtensions returns the item's Media RSS elements with the given name,
+// including those within media:group elements
+func findMediaExtensions(item *gofeed.Item, name string) []ext.Extension {
+	extensions := append([]ext.Extension{}, item.Extensions["media"][name]...)
+	for _, group := range item.Extensions["media"]["group"] {
+		extensions = append(extensions, group.Children[name]...)
+	}
+	return extensions
+}
+
+// findPublished returns the item's publication date, falling back to the date it
+// was last updated, or nil if neither can be parsed
+func findPublished(item *gofeed.Item) *Timestamp {
+	if item.PublishedParsed != nil {
+		return NewTimestamp(*item.PublishedParsed)
+	}
+	if item.UpdatedParsed != nil {
+		return NewTimestamp(*item.UpdatedParsed)
+	}
+	for _, s := range []string{item.Published, item.Updated} {
+		if t, err := ParseTimestamp(strings.TrimSpace(s)); err == nil {
+			return NewTimestamp(t)
+		}
+	}
+	return nil
 }
 
 func processAuthor(item *gofeed.Item) string {
@@ -1,6 +1,7 @@
 package content
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"testing"
@@ -8,6 +9,13 @@ import (
 	"net/http"
 
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"mozilla.org/crec/content/processor"
 )
 
 func TestIngesterReusesExistingContentOnError(t *testing.T) {
@@ -77,15 +85,13 @@ func TestIngestNative(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	p := &Provider{ID: "test", ContentURL: ts.URL, Domains: map[string]float32{"d": 0.9}}
-	index := CreateIndex(&TestConfig{})
+	p := &Provider{ID: "test", ContentURL: ts.URL, Native: true, Domains: map[string]float32{"d": 0.9}}
 
-	err := ingestNative(p, &http.Client{}, index)
+	content, _, _, err := ingestFromURL(p, &http.Client{}, nil, &Index{}, &Report{})
 	if err != nil {
 		t.Error(err)
 	}
 
-	content := index.GetContent()
 	if len(content) != 1 {
 		t.Fatalf("Expected new index to contain content of length 1, but got %v", len(content))
 	}
@@ -104,14 +110,12 @@ func TestIngestSyndicationFeed(t *testing.T) {
 	defer ts.Close()
 
 	p := &Provider{ID: "test", ContentURL: ts.URL, Domains: map[string]float32{"d": 0.9}}
-	index := CreateIndex(&TestConfig{})
 
-	err := ingestSyndicationFeed(p, &http.Client{}, index)
+	content, _, _, err := ingestFromURL(p, &http.Client{}, nil, &Index{}, &Report{})
 	if err != nil {
 		t.Error(err)
 	}
 
-	content := index.GetContent()
 	if len(content) != 1 {
 		t.Fatalf("Expected new index to contain content of length 1, but got %v", len(content))
 	}
@@ -125,3 +129,335 @@ func TestIngestSyndicationFeed(t *testing.T) {
 		t.Errorf("Expected content type to be RECOMMENDED, but got %v", content[0].CType)
 	}
 }
+
+func TestIngestSyndicationFeedParsesPublicationDates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `<rss><channel>
+			<item><guid>0</guid><pubDate>Sun, 17 Sep 2017 15:53:05 +0200</pubDate></item>
+			<item><guid>1</guid></item>
+		</channel></rss>`)
+	}))
+	defer ts.Close()
+
+	content, _, _, err := ingestFromURL(&Provider{ID: "test", ContentURL: ts.URL}, &http.Client{}, nil, &Index{}, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content[0].Published == nil || content[0].Published.Format(time.RFC3339) != "2017-09-17T13:53:05Z" {
+		t.Errorf("Expected publication date in UTC, but got %v", content[0].Published)
+	}
+	if content[1].Published != nil {
+		t.Errorf("Expected no publication date, but got %v", content[1].Published)
+	}
+}
+
+func TestIngestDropsContentExceedingMaxItemAge(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id":"0","published_timestamp":"%v"},{"id":"1","published_timestamp":"%v"},{"id":"2"}]`,
+			time.Now().Add(-time.Hour).Format(time.RFC3339), time.Now().Add(-3*time.Hour).Format(time.RFC3339))
+	}))
+	defer ts.Close()
+
+	config := &TestConfig{}
+	p := &Provider{ID: "test-max-age", ContentURL: ts.URL, Native: true, MaxItemAge: 2}
+	defer os.RemoveAll(filepath.Join(config.GetImportQueueDir(), "test-max-age"))
+
+	index := Ingest(config, Providers{"test-max-age": p}, &Index{})
+	assertContentIDs(t, index.GetProviderContent("test-max-age"), "0", "2")
+}
+
+type failingProcessor struct{}
+
+func (p failingProcessor) Process(context *processor.Context) (*processor.Context, error) {
+	if context.Content.(*html.Node).FirstChild.LastChild.FirstChild != nil {
+		return nil, errors.New("Unsupported content")
+	}
+	return context, nil
+}
+
+func TestIngestSyndicationFeedSkipsInvalidItems(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `<rss><channel><item><guid>0</guid></item><item><guid>1</guid><description>bad</description></item><item><guid>2</guid></item></channel></rss>`)
+	}))
+	defer ts.Close()
+
+	config := &TestConfig{}
+	p := &Provider{ID: "test-rejects", ContentURL: ts.URL, processors: []processor.Processor{failingProcessor{}}}
+	defer os.RemoveAll(filepath.Join(config.GetImportQueueDir(), "test-rejects"))
+
+	index := Ingest(config, Providers{"test-rejects": p}, &Index{})
+	assertContentIDs(t, index.GetProviderContent("test-rejects"), "0", "2")
+
+	reports := index.GetReports("test-rejects")
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 report, but got %v", len(reports))
+	}
+	report := reports[0]
+	if report.Type != "fetch" || report.Status != http.StatusOK || report.Accepted != 2 || report.Error != "" {
+		t.Errorf("Unexpected report %v", report)
+	}
+	if len(report.Rejected) != 1 || report.Rejected[0].ID != "1" || report.Rejected[0].Reason != "Unsupported content" {
+		t.Errorf("Expected item 1 to be rejected, but got %v", report.Rejected)
+	}
+}
+
+func TestIngestSyndicationFeedDiscoversImages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `<rss xmlns:media="http://search.yahoo.com/mrss/" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"><channel>
+			<item><guid>0</guid><link>https://example.com/a/0</link><media:content url="/video.mp4" medium="video"/><media:thumbnail url="/0.jpg"/></item>
+			<item><guid>1</guid><link>https://example.com/a/1</link><enclosure url="http://example.com/1.mp3" type="audio/mpeg"/><enclosure url="http://example.com/1.jpg" type="image/jpeg"/></item>
+			<item><guid>2</guid><link>https://example.com/a/2</link><itunes:image href="http://example.com/2.jpg"/><description><![CDATA[<img src="https://example.com/2-https.jpg">]]></description></item>
+			<item><guid>3</guid><link>https://example.com/a/3</link><media:group><media:content url="3.jpg" type="image/jpeg"/></media:group></item>
+		</channel></rss>`)
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test", ContentURL: ts.URL, processors: []processor.Processor{processor.ImageExtractor{}}}
+	content, _, _, err := ingestFromURL(p, &http.Client{}, nil, &Index{}, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"https://example.com/0.jpg", "http://example.com/1.jpg", "https://example.com/2-https.jpg", "https://example.com/a/3.jpg"}
+	if len(content) != len(want) {
+		t.Fatalf("Expected content of length %v, but got %v", len(want), len(content))
+	}
+	for i, c := range content {
+		if c.Image != want[i] {
+			t.Errorf("Expected image %v for item %v, but got %v", want[i], c.ID, c.Image)
+		}
+	}
+}
+
+type articleProcessor struct{}
+
+func (p articleProcessor) Process(context *processor.Context) (*processor.Context, error) {
+	context.Result["html"] = "<p>Article of " + context.URL + "</p>"
+	context.Result["excerpt"] = "Article"
+	context.Result["image"] = "http://example.com/article.png"
+	return context, nil
+}
+
+func TestIngestSyndicationFeedUsesProcessorResults(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `<rss><channel><item><guid>0</guid><link>http://example.com/0</link><description>Teaser</description></item></channel></rss>`)
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test", ContentURL: ts.URL, processors: []processor.Processor{articleProcessor{}}}
+	content, _, _, err := ingestFromURL(p, &http.Client{}, nil, &Index{}, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 1 {
+		t.Fatalf("Expected content of length 1, but got %v", len(content))
+	}
+	c := content[0]
+	if c.HTML != "<p>Article of http://example.com/0</p>" || c.Excerpt != "Article" || c.Image != "http://example.com/article.png" {
+		t.Errorf("Expected HTML, excerpt and image of processors, but got %v", c)
+	}
+}
+
+func TestIngestFromProviderSendsValidators(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == "v1" && r.Header.Get("If-Modified-Since") == "lm1" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", "v1")
+		w.Header().Set("Last-Modified", "lm1")
+		fmt.Fprintln(w, `<rss><channel><item><guid>0</guid></item></channel></rss>`)
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test", ContentURL: ts.URL}
+	content, validators, _, err := ingestFromProvider(&TestConfig{}, p, &Index{}, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Validators{ETag: "v1", LastModified: "lm1"}
+	if validators != want {
+		t.Errorf("Expected validators %v, but got %v", want, validators)
+	}
+
+	curIndex := CreateIndex(&TestConfig{})
+	curIndex.Add(content)
+	curIndex.SetProviderValidators("test", validators)
+
+	content, validators, _, err = ingestFromProvider(&TestConfig{}, p, curIndex, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(content) != 1 {
+		t.Fatalf("Expected content of length 1, but got %v", len(content))
+	}
+	if content[0] != curIndex.GetContent()[0] {
+		t.Error("Expected unmodified content to be reused from current index")
+	}
+	if validators != want {
+		t.Errorf("Expected validators %v, but got %v", want, validators)
+	}
+}
+
+func TestIngestFromProviderRetriesTransientErrors(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, `<rss><channel><item><guid>0</guid></item></channel></rss>`)
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test", ContentURL: ts.URL}
+	content, _, _, err := ingestFromProvider(&TestConfig{}, p, &Index{}, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected exactly 2 requests, but got %v", requests)
+	}
+	if len(content) != 1 {
+		t.Errorf("Expected content of length 1, but got %v", len(content))
+	}
+}
+
+func TestIngestSuspendsFailingProvider(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	config := &TestConfig{}
+	providers := Providers{"test": &Provider{ID: "test", ContentURL: ts.URL}}
+	curIndex := CreateIndex(config)
+	curIndex.AddItem(&Content{ID: "0", Source: "test"})
+
+	index := Ingest(config, providers, curIndex)
+	status := index.GetProviderStatus("test")
+	if status.ConsecutiveFailures != 1 || status.Tripped() {
+		t.Errorf("Expected one failure without suspension, but got %v", status)
+	}
+
+	index = Ingest(config, providers, index)
+	status = index.GetProviderStatus("test")
+	if status.ConsecutiveFailures != 2 || !status.Tripped() {
+		t.Errorf("Expected provider to be suspended after two failures, but got %v", status)
+	}
+
+	index = Ingest(config, providers, index)
+	if requests != 2 {
+		t.Errorf("Expected no requests to suspended provider, but got %v in total", requests)
+	}
+	if len(index.GetContent()) != 1 {
+		t.Errorf("Expected content of suspended provider to be reused, but got %v", index.GetContent())
+	}
+	if !index.GetProviderStatus("test").Tripped() {
+		t.Error("Expected provider to remain suspended")
+	}
+}
+
+func TestIngestFromProviderUsesHTTPSettings(t *testing.T) {
+	os.Setenv("CREC_TEST_USER", "user")
+	os.Setenv("CREC_TEST_PASSWORD", "password")
+	defer os.Unsetenv("CREC_TEST_USER")
+	defer os.Unsetenv("CREC_TEST_PASSWORD")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "user" || password != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("User-Agent") != "crec-test" || r.Header.Get("X-Custom") != "custom" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, `[{"id":"0"}]`)
+	}))
+	defer ts.Close()
+
+	p := &Provider{
+		ID:                   "test",
+		ContentURL:           ts.URL,
+		Native:               true,
+		UserAgent:            "crec-test",
+		Headers:              map[string]string{"X-Custom": "custom"},
+		BasicAuthUserEnv:     "CREC_TEST_USER",
+		BasicAuthPasswordEnv: "CREC_TEST_PASSWORD"}
+
+	content, _, _, err := ingestFromProvider(&TestConfig{}, p, &Index{}, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 1 {
+		t.Errorf("Expected content of length 1, but got %v", len(content))
+	}
+
+	p.BasicAuthUserEnv = ""
+	p.BearerTokenEnv = "CREC_TEST_UNDEFINED"
+	_, _, _, err = ingestFromProvider(&TestConfig{}, p, &Index{}, &Report{})
+	if err == nil {
+		t.Error("Expected error for undefined bearer token")
+	}
+}
+
+func TestIngestFromProviderEnforcesMaxContentSize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":"0"}]`)
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test", ContentURL: ts.URL, Native: true, MaxContentSize: 5}
+	_, _, _, err := ingestFromProvider(&TestConfig{}, p, &Index{}, &Report{})
+	if err == nil {
+		t.Error("Expected error for content exceeding maximum size")
+	}
+
+	p.MaxContentSize = 12
+	_, _, _, err = ingestFromProvider(&TestConfig{}, p, &Index{}, &Report{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIngestReusesFullTextIndex(t *testing.T) {
+	config := &TestConfig{}
+	providers := Providers{"test-incremental": &Provider{ID: "test-incremental"}}
+	defer os.RemoveAll(filepath.Join(config.GetImportQueueDir(), "test-incremental"))
+
+	err := Enqueue(config, []byte(`[{"id":"0","title":"first"}]`), "test-incremental")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := Ingest(config, providers, &Index{})
+
+	err = Enqueue(config, []byte(`[{"id":"1","title":"second"}]`), "test-incremental")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newIndex := Ingest(config, providers, index)
+
+	if newIndex.GetID() == index.GetID() {
+		t.Error("Expected new index ID")
+	}
+	if newIndex.fullTextID != index.fullTextID {
+		t.Error("Expected full-text index to be reused")
+	}
+	hits, err := newIndex.Query("second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 {
+		t.Errorf("Expected exactly one hit, but got %v", len(hits))
+	}
+	if _, err := os.Stat(filepath.Join(config.GetFullTextIndexDir(), index.fullTextID)); err != nil {
+		t.Errorf("Expected full-text index to be retained: %v", err)
+	}
+}
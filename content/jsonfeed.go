@@ -0,0 +1,197 @@
+package content
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"mozilla.org/crec/content/processor"
+)
+
+// Prefix of the version URL identifying a JSON Feed e.g. https://jsonfeed.org/version/1.1
+const jsonFeedVersionPrefix = "jsonfeed.org/version/"
+
+// jsonFeed represents a JSON Feed (see https://jsonfeed.org/version/1.1)
+type jsonFeed struct {
+	Version  string           `json:"version"`
+	FeedURL  string           `json:"feed_url"`
+	Language string           `json:"language"`
+	Author   *jsonFeedAuthor  `json:"author"`
+	Authors  []jsonFeedAuthor `json:"authors"`
+	Hubs     []jsonFeedHub    `json:"hubs"`
+	Items    []jsonFeedItem   `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            json.RawMessage  `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	Image         string           `json:"image"`
+	BannerImage   string           `json:"banner_image"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Author        *jsonFeedAuthor  `json:"author"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	Tags          []string         `json:"tags"`
+	Language      string           `json:"language"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// isJSONFeed returns true if the body holds a JSON Feed
+func isJSONFeed(body []byte) bool {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return false
+	}
+	var feed struct {
+		Version string `json:"version"`
+	}
+	return json.Unmarshal(body, &feed) == nil && strings.Contains(feed.Version, jsonFeedVersionPrefix)
+}
+
+// parseJSONFeed parses a JSON Feed (version 1 or 1.1), applying the provider's
+// processors to the items' HTML content. Items which can't be processed are
+// skipped and recorded in the report.
+func parseJSONFeed(body []byte, provider *Provider, pages *pageFetcher, report *Report) ([]*Content, error) {
+	var feed jsonFeed
+	err := json.Unmarshal(body, &feed)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(feed.Version, jsonFeedVersionPrefix) {
+		return nil, errors.New("Not a JSON Feed, unknown version " + feed.Version)
+	}
+
+	content := make([]*Content, 0)
+	for _, item := range feed.Items {
+		newc, err := createContentFromJSONFeedItem(provider, &feed, &item, pages)
+		if err != nil {
+			report.reject(newc.ID, err)
+			continue
+		}
+		content = append(content, newc)
+	}
+	return content, nil
+}
+
+// createContentFromJSONFeedItem maps the item to our format. Items without ID and
+// URL are rejected. The returned content holds the item's ID even if an error is
+// returned.
+func createContentFromJSONFeedItem(provider *Provider, feed *jsonFeed, item *jsonFeedItem, pages *pageFetcher) (*Content, error) {
+	newc := &Content{
+		ID:       jsonFeedItemID(item),
+		Source:   provider.ID,
+		Title:    item.Title,
+		URL:      item.URL,
+		Excerpt:  item.Summary,
+		HTML:     item.ContentHTML,
+		Tags:     append(item.Tags, provider.Categories...),
+		Author:   jsonFeedAuthorNames(item, feed),
+		Regions:  provider.Regions,
+		Language: provider.Language,
+		Script:   provider.Script,
+		Domains:  provider.Domains,
+		CType:    RECOMMENDED}
+
+	if newc.URL == "" {
+		newc.URL = item.ExternalURL
+	}
+	if newc.ID == "" {
+		newc.ID = newc.URL
+	}
+	if newc.ID == "" {
+		return newc, errors.New("Missing id, url and external_url")
+	}
+	if newc.Language == "" {
+		newc.Language = item.Language
+	}
+	if newc.Language == "" {
+		newc.Language = feed.Language
+	}
+	for _, date := range []string{item.DatePublished, item.DateModified} {
+		if t, err := ParseTimestamp(strings.TrimSpace(date)); err == nil {
+			newc.Published = NewTimestamp(t)
+			break
+		}
+	}
+
+	summary, context, err := processHTML(provider, item.ContentHTML, newc.URL, pages)
+	if err != nil {
+		return newc, err
+	}
+	newc.HTML = findHTML(newc.HTML, context)
+	if newc.Excerpt == "" {
+		newc.Excerpt = findExcerpt(summary, context)
+	}
+	newc.Image = processor.PreferHTTPS(processor.ResolveImageURL(newc.URL, item.Image),
+		processor.ResolveImageURL(newc.URL, item.BannerImage), context.Result["image"])
+	if newc.Excerpt == "" {
+		newc.Excerpt = item.ContentText
+	}
+	return maybeAppendExplanation(newc), nil
+}
+
+// jsonFeedItemID returns the item's ID, which should be a string, but is a number in some feeds
+func jsonFeedItemID(item *jsonFeedItem) string {
+	var id string
+	if json.Unmarshal(item.ID, &id) == nil {
+		return id
+	}
+	var number json.Number
+	if json.Unmarshal(item.ID, &number) == nil {
+		return number.String()
+	}
+	return ""
+}
+
+// jsonFeedAuthorNames returns the names of the item's authors (authors in version 1.1,
+// author in version 1), falling back to the authors of the feed
+func jsonFeedAuthorNames(item *jsonFeedItem, feed *jsonFeed) string {
+	candidates := [][]jsonFeedAuthor{item.Authors, nil, feed.Authors, nil}
+	if item.Author != nil {
+		candidates[1] = []jsonFeedAuthor{*item.Author}
+	}
+	if feed.Author != nil {
+		candidates[3] = []jsonFeedAuthor{*feed.Author}
+	}
+
+	for _, authors := range candidates {
+		names := make([]string, 0)
+		for _, author := range authors {
+			if author.Name != "" {
+				names = append(names, author.Name)
+			}
+		}
+		if len(names) > 0 {
+			return strings.Join(names, ", ")
+		}
+	}
+	return ""
+}
+
+// parseJSONFeedLinks returns the WebSub hub and feed URL of a JSON Feed
+func parseJSONFeedLinks(body []byte) (string, string) {
+	var feed jsonFeed
+	if json.Unmarshal(body, &feed) != nil {
+		return "", ""
+	}
+	for _, hub := range feed.Hubs {
+		if strings.EqualFold(hub.Type, "websub") && hub.URL != "" {
+			return hub.URL, feed.FeedURL
+		}
+	}
+	return "", feed.FeedURL
+}
@@ -0,0 +1,96 @@
+package content
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"mozilla.org/crec/content/processor"
+)
+
+func TestIngestJSONFeed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{
+			"version": "https://jsonfeed.org/version/1.1",
+			"language": "de",
+			"authors": [{"name": "Feed Author"}],
+			"items": [{
+				"id": "0",
+				"url": "https://example.com/0",
+				"title": "t0",
+				"content_html": "<p>Some <b>HTML</b></p>",
+				"summary": "Summary",
+				"image": "https://example.com/0.png",
+				"tags": ["t1", "t2"],
+				"authors": [{"name": "A1"}, {"name": "A2"}],
+				"date_published": "2017-09-17T15:53:05+02:00"
+			}, {
+				"id": 1,
+				"external_url": "https://example.com/1",
+				"content_html": "<p>Some <b>HTML</b></p><img src=\"http://example.com/1.png\">"
+			}, {
+				"id": "2",
+				"content_text": "Text"
+			}, {
+				"title": "Missing ID",
+				"content_text": "Text"
+			}]}`)
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test", ContentURL: ts.URL, Categories: []string{"c"}, processors: []processor.Processor{
+		processor.BoldElementRemover{}, processor.ImageExtractor{}}}
+	report := &Report{}
+	content, _, _, err := ingestFromURL(p, &http.Client{}, nil, &Index{}, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 3 {
+		t.Fatalf("Expected content of length 3, but got %v", len(content))
+	}
+	if len(report.Rejected) != 1 {
+		t.Errorf("Expected item without ID and URL to be rejected, but got %v", report.Rejected)
+	}
+
+	c := content[0]
+	if c.ID != "0" || c.Source != "test" || c.Title != "t0" || c.URL != "https://example.com/0" || c.Excerpt != "Summary" ||
+		c.Image != "https://example.com/0.png" || c.HTML != "<p>Some <b>HTML</b></p>" || c.Author != "A1, A2" || c.Language != "de" {
+		t.Errorf("Unexpected content %v", c)
+	}
+	if !reflect.DeepEqual(c.Tags, []string{"t1", "t2", "c"}) {
+		t.Errorf("Expected tags and provider categories, but got %v", c.Tags)
+	}
+	if c.Published == nil || !c.Published.Equal(time.Date(2017, 9, 17, 13, 53, 5, 0, time.UTC)) {
+		t.Errorf("Expected publication date, but got %v", c.Published)
+	}
+
+	c = content[1]
+	if c.ID != "1" || c.URL != "https://example.com/1" || c.Excerpt != "Some" || c.Image != "http://example.com/1.png" || c.Author != "Feed Author" {
+		t.Errorf("Expected defaults derived from HTML content, but got %v", c)
+	}
+	if content[2].Excerpt != "Text" {
+		t.Errorf("Expected text content as excerpt, but got %v", content[2].Excerpt)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{` [{"id":"0"}]`, FormatNative},
+		{`{"version":"https://jsonfeed.org/version/1","items":[]}`, FormatJSONFeed},
+		{`{"items":[]}`, FormatFeed},
+		{`<rss><channel></channel></rss>`, FormatFeed},
+	}
+
+	for _, test := range tests {
+		got := detectFormat([]byte(test.body))
+		if got != test.want {
+			t.Errorf("Expected %v for %v, but got %v", test.want, test.body, got)
+		}
+	}
+}
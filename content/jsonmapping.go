@@ -0,0 +1,191 @@
+package content
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mozilla.org/crec/content/processor"
+)
+
+// FieldMapping declares where to find content in a provider's JSON responses,
+// using paths as supported by processor.Lookup e.g. data.items or media.0.url.
+// All paths but Items are relative to an item.
+type FieldMapping struct {
+	// Path to the list of items, the response itself if omitted.
+	Items string
+
+	ID        string
+	Title     string
+	URL       string
+	Image     string
+	Excerpt   string
+	HTML      string
+	Tags      string
+	Author    string
+	Published string
+	Language  string
+}
+
+// validate checks that the mapping declares all fields required to create content
+func (m *FieldMapping) validate() error {
+	if m == nil {
+		return errors.New("Missing field mapping")
+	}
+	if m.Title == "" || m.URL == "" {
+		return errors.New("Field mapping requires paths for Title and URL")
+	}
+	return nil
+}
+
+// parseMappedJSON creates content from arbitrary JSON using the provider's field
+// mapping. Each item is passed to the provider's processors as JSON first, and
+// its mapped HTML (if any) afterwards. Items which can't be mapped are skipped and recorded
+// in the report.
+func parseMappedJSON(body []byte, provider *Provider, pages *pageFetcher, report *Report) ([]*Content, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc interface{}
+	err := decoder.Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := provider.Mapping
+	items := make([]interface{}, 0)
+	for _, v := range processor.Lookup(doc, mapping.Items) {
+		if list, ok := v.([]interface{}); ok {
+			items = append(items, list...)
+		} else {
+			items = append(items, v)
+		}
+	}
+
+	content := make([]*Content, 0)
+	for _, item := range items {
+		newc, err := createContentFromJSONItem(provider, item, pages)
+		if err != nil {
+			report.reject(newc.ID, err)
+			continue
+		}
+		content = append(content, newc)
+	}
+	return content, nil
+}
+
+// createContentFromJSONItem maps the item to our format. The returned content
+// holds the item's ID even if an error is returned.
+func createContentFromJSONItem(provider *Provider, item interface{}, pages *pageFetcher) (*Content, error) {
+	mapping := provider.Mapping
+	var err error
+	var context = processor.NewJSONContext(item)
+	for _, processor := range provider.GetProcessors() {
+		context, err = processor.Process(context)
+		if err != nil {
+			return &Content{ID: lookupID(item, mapping)}, err
+		}
+	}
+	item = context.Content
+
+	newc := &Content{
+		ID:       lookupID(item, mapping),
+		Source:   provider.ID,
+		Title:    lookupString(item, mapping.Title),
+		URL:      lookupString(item, mapping.URL),
+		Image:    lookupString(item, mapping.Image),
+		Excerpt:  lookupString(item, mapping.Excerpt),
+		HTML:     lookupString(item, mapping.HTML),
+		Tags:     append(lookupStrings(item, mapping.Tags), provider.Categories...),
+		Author:   strings.Join(lookupStrings(item, mapping.Author), ", "),
+		Regions:  provider.Regions,
+		Language: provider.Language,
+		Script:   provider.Script,
+		Domains:  provider.Domains,
+		CType:    RECOMMENDED}
+
+	if newc.Title == "" || newc.URL == "" {
+		return newc, fmt.Errorf("Missing title or URL (mapped from %v and %v)", mapping.Title, mapping.URL)
+	}
+	if newc.Language == "" {
+		newc.Language = lookupString(item, mapping.Language)
+	}
+	newc.Published = lookupTimestamp(item, mapping.Published)
+
+	summary, htmlContext, err := processHTML(provider, newc.HTML, newc.URL, pages)
+	if err != nil {
+		return newc, err
+	}
+	newc.HTML = findHTML(newc.HTML, htmlContext)
+	if newc.Excerpt == "" {
+		newc.Excerpt = findExcerpt(summary, htmlContext)
+	}
+	newc.Image = processor.PreferHTTPS(processor.ResolveImageURL(newc.URL, newc.Image),
+		context.Result["image"], htmlContext.Result["image"])
+	return maybeAppendExplanation(newc), nil
+}
+
+// lookupID returns the item's ID, falling back to its URL
+func lookupID(item interface{}, mapping *FieldMapping) string {
+	id := lookupString(item, mapping.ID)
+	if id == "" {
+		id = lookupString(item, mapping.URL)
+	}
+	return id
+}
+
+// lookupStrings returns all scalar values found at the path as strings, expanding lists
+func lookupStrings(item interface{}, path string) []string {
+	result := make([]string, 0)
+	if path == "" {
+		return result
+	}
+	for _, v := range processor.Lookup(item, path) {
+		values, ok := v.([]interface{})
+		if !ok {
+			values = []interface{}{v}
+		}
+		for _, value := range values {
+			switch value := value.(type) {
+			case string:
+				result = append(result, value)
+			case json.Number, bool:
+				result = append(result, fmt.Sprint(value))
+			}
+		}
+	}
+	return result
+}
+
+// lookupString returns the first scalar value found at the path as a string
+func lookupString(item interface{}, path string) string {
+	values := lookupStrings(item, path)
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[0])
+}
+
+// lookupTimestamp returns the date found at the path, either as a string in one
+// of the formats supported by ParseTimestamp or as a number of seconds since the
+// Unix epoch
+func lookupTimestamp(item interface{}, path string) *Timestamp {
+	if path == "" {
+		return nil
+	}
+	for _, v := range processor.Lookup(item, path) {
+		switch v := v.(type) {
+		case string:
+			if t, err := ParseTimestamp(strings.TrimSpace(v)); err == nil {
+				return NewTimestamp(t)
+			}
+		case json.Number:
+			if seconds, err := v.Int64(); err == nil {
+				return NewTimestamp(time.Unix(seconds, 0))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package content
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"mozilla.org/crec/content/processor"
+)
+
+type titleCaser struct{}
+
+func (p titleCaser) Process(context *processor.Context) (*processor.Context, error) {
+	if context.JSON {
+		item := context.Content.(map[string]interface{})
+		item["headline"] = "Processed " + item["headline"].(string)
+		context.Result["image"] = "https://example.com/processed.png"
+	}
+	return context, nil
+}
+
+func TestIngestMappedJSON(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"items": [{
+				"id": 7,
+				"headline": "t0",
+				"links": {"web": "https://example.com/0"},
+				"media": [{"url": "https://example.com/0.png"}],
+				"teaser": "Teaser",
+				"tags": [{"name": "t1"}, {"name": "t2"}],
+				"byline": ["A1", "A2"],
+				"published": "2017-09-17T15:53:05+02:00"
+			}, {
+				"headline": "t1",
+				"links": {"web": "https://example.com/1"},
+				"body": "<p>Some <a href=\"https://example.com\">HTML</a></p>",
+				"published": 1505656385
+			}, {
+				"headline": "t2"
+			}]}}`))
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test", ContentURL: ts.URL, Categories: []string{"c"},
+		processors: []processor.Processor{titleCaser{}, processor.ExternalLinkRemover{}},
+		Mapping: &FieldMapping{Items: "data.items", ID: "id", Title: "headline", URL: "links.web", Image: "media.0.url",
+			Excerpt: "teaser", HTML: "body", Tags: "tags.*.name", Author: "byline", Published: "published"}}
+	report := &Report{}
+	content, _, _, err := ingestFromURL(p, &http.Client{}, nil, &Index{}, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 2 {
+		t.Fatalf("Expected content of length 2, but got %v", len(content))
+	}
+	if len(report.Rejected) != 1 {
+		t.Errorf("Expected item without URL to be rejected, but got %v", report.Rejected)
+	}
+
+	c := content[0]
+	if c.ID != "7" || c.Source != "test" || c.Title != "Processed t0" || c.URL != "https://example.com/0" ||
+		c.Image != "https://example.com/0.png" || c.Excerpt != "Teaser" || c.Author != "A1, A2" {
+		t.Errorf("Unexpected content %v", c)
+	}
+	if !reflect.DeepEqual(c.Tags, []string{"t1", "t2", "c"}) {
+		t.Errorf("Expected mapped tags and provider categories, but got %v", c.Tags)
+	}
+	if c.Published == nil || !c.Published.Equal(time.Date(2017, 9, 17, 13, 53, 5, 0, time.UTC)) {
+		t.Errorf("Expected publication date, but got %v", c.Published)
+	}
+
+	c = content[1]
+	if c.ID != "https://example.com/1" || c.Excerpt != "Some" || c.Image != "https://example.com/processed.png" {
+		t.Errorf("Expected defaults derived from URL, HTML and processors, but got %v", c)
+	}
+	if c.Published == nil || !c.Published.Equal(time.Date(2017, 9, 17, 13, 53, 5, 0, time.UTC)) {
+		t.Errorf("Expected publication date from Unix time, but got %v", c.Published)
+	}
+}
+
+func TestIngestMappedJSONWithJSONFilter(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items": [
+			{"headline": "t0", "url": "https://example.com/0", "labels": [{"name": "news"}]},
+			{"headline": "t1", "url": "https://example.com/1", "labels": [{"name": "Sponsored"}]},
+			{"headline": "t2", "url": "https://example.com/2", "body": "<p>Some <a href=\"https://example.com\">HTML</a></p>"}]}`))
+	}))
+	defer ts.Close()
+
+	var p Provider
+	_, err := toml.Decode(`
+ID = "test"
+ContentURL = "`+ts.URL+`"
+Processors = ["sponsored", "ExternalLinkRemover"]
+
+[Mapping]
+Items = "items"
+Title = "headline"
+URL = "url"
+HTML = "body"
+
+[ProcessorConfig.sponsored]
+Type = "JSONFilter"
+Path = "labels.*.name"
+Pattern = "(?i)^sponsored$"
+`, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.createProcessors(processor.GetRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := &Report{}
+	content, _, _, err := ingestFromURL(&p, &http.Client{}, nil, &Index{}, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, content, "https://example.com/0", "https://example.com/2")
+	if len(report.Rejected) != 1 || report.Rejected[0].ID != "https://example.com/1" {
+		t.Errorf("Expected sponsored item to be rejected, but got %v", report.Rejected)
+	}
+	if strings.Contains(content[1].Excerpt, "HTML") {
+		t.Errorf("Expected HTML processors to apply to the remaining items, but got %v", content[1].Excerpt)
+	}
+}
+
+func TestGetFormatWithMapping(t *testing.T) {
+	p := &Provider{Mapping: &FieldMapping{Title: "title", URL: "url"}}
+	if p.GetFormat() != FormatJSON {
+		t.Errorf("Expected format %v for provider with mapping, but got %v", FormatJSON, p.GetFormat())
+	}
+	if (&Provider{Format: FormatJSON}).init(processor.GetRegistry()) == nil {
+		t.Error("Expected error for provider without mapping")
+	}
+}
@@ -0,0 +1,180 @@
+package content
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Weight of terms in the title of content relative to terms in the excerpt
+const titleTermWeight = 2
+
+// Minimum number of letters of words in extracted tags
+const minTagWordLength = 3
+
+// Languages which don't separate words by spaces, tags aren't extracted from
+// content in these languages
+var unsegmentedLanguages = map[string]bool{"ja": true, "zh": true, "th": true}
+
+// Matches punctuation separating phrases
+var phraseSeparator = regexp.MustCompile(`[^\p{L}\p{N}\s'’-]+`)
+
+// termStatistics holds the number of documents (the title and excerpt of content)
+// containing each term, used to weigh terms by their inverse document frequency
+type termStatistics struct {
+	documents   int
+	frequencies map[string]int
+}
+
+func newTermStatistics() *termStatistics {
+	return &termStatistics{frequencies: make(map[string]int)}
+}
+
+// add counts the distinct terms of the content
+func (s *termStatistics) add(c *Content) {
+	s.documents++
+	for term := range termFrequencies(c) {
+		s.frequencies[term]++
+	}
+}
+
+// idf returns the inverse document frequency of the term in the combined statistics
+func idf(term string, stats ...*termStatistics) float64 {
+	documents, frequency := 0, 0
+	for _, s := range stats {
+		documents += s.documents
+		frequency += s.frequencies[term]
+	}
+	return math.Log(float64(documents+1)/float64(frequency+1)) + 1
+}
+
+// extractTags adds up to limit keyphrases, extracted from the title and excerpt,
+// to the tags of content without tags of its own (i.e. only the provider's
+// categories). Terms (words and pairs of adjacent words, excluding stopwords of
+// the content's language) are ranked by their TF-IDF, using the statistics of
+// the current index and the provided content. The extracted tags are marked as
+// GeneratedTags.
+func extractTags(limit int, provider *Provider, curIndex *Index, content []*Content) {
+	if limit <= 0 {
+		return
+	}
+
+	corpus := curIndex.getTermStatistics()
+	batch := newTermStatistics()
+	for _, c := range content {
+		batch.add(c)
+	}
+
+	for _, c := range content {
+		if hasOwnTags(provider, c) || unsegmentedLanguages[localeLanguage(c.Language)] {
+			continue
+		}
+
+		frequencies := termFrequencies(c)
+		terms := make([]string, 0, len(frequencies))
+		scores := make(map[string]float64)
+		for term, tf := range frequencies {
+			terms = append(terms, term)
+			scores[term] = tf * idf(term, corpus, batch)
+		}
+		sort.Slice(terms, func(i, j int) bool {
+			if scores[terms[i]] != scores[terms[j]] {
+				return scores[terms[i]] > scores[terms[j]]
+			}
+			return terms[i] < terms[j]
+		})
+
+		tags := make([]string, 0)
+		for _, term := range terms {
+			if len(tags) == limit {
+				break
+			}
+			if !overlaps(term, tags) && !hasTag(c, term) {
+				tags = append(tags, term)
+			}
+		}
+		if len(tags) > 0 {
+			c.GeneratedTags = tags
+			c.Tags = append(append([]string{}, tags...), c.Tags...)
+		}
+	}
+}
+
+// hasOwnTags returns true if the content has tags other than the provider's categories
+func hasOwnTags(provider *Provider, c *Content) bool {
+	categories := make(map[string]bool)
+	for _, category := range provider.Categories {
+		categories[category] = true
+	}
+	for _, tag := range c.Tags {
+		if !categories[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag returns true if the content has the tag, ignoring case
+func hasTag(c *Content, tag string) bool {
+	for _, t := range c.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps returns true if the term shares a word with any of the tags
+func overlaps(term string, tags []string) bool {
+	for _, tag := range tags {
+		for _, word := range strings.Fields(term) {
+			for _, w := range strings.Fields(tag) {
+				if word == w {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// termFrequencies returns the weighted frequencies of the terms in the title and
+// excerpt of the content. Terms are lower-cased words and pairs of adjacent words
+// within a phrase, neither separated by punctuation nor a stopword.
+func termFrequencies(c *Content) map[string]float64 {
+	frequencies := make(map[string]float64)
+	stop := stopwordsOf(c)
+	add := func(text string, weight float64) {
+		for _, phrase := range phraseSeparator.Split(strings.ToLower(text), -1) {
+			prev := ""
+			for _, word := range strings.Fields(phrase) {
+				word = strings.Trim(word, "'’-")
+				if stop[word] || !isTagWord(word) {
+					prev = ""
+					continue
+				}
+				frequencies[word] += weight
+				if prev != "" {
+					frequencies[prev+" "+word] += weight
+				}
+				prev = word
+			}
+		}
+	}
+	add(c.Title, titleTermWeight)
+	add(c.Excerpt, 1)
+	return frequencies
+}
+
+// isTagWord returns true if the word is long enough and contains a letter
+func isTagWord(word string) bool {
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters >= minTagWordLength
+}
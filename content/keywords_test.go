@@ -0,0 +1,45 @@
+package content
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTermFrequencies(t *testing.T) {
+	frequencies := termFrequencies(&Content{Title: "The Mars rover", Excerpt: "NASA's rover landed on Mars, 2 days ago.", Language: "en"})
+	want := map[string]float64{"mars": 3, "rover": 3, "mars rover": 2, "nasa's": 1, "nasa's rover": 1,
+		"landed": 1, "rover landed": 1, "days": 1, "ago": 1, "days ago": 1}
+	if !reflect.DeepEqual(frequencies, want) {
+		t.Errorf("Expected term frequencies %v, but got %v", want, frequencies)
+	}
+}
+
+func TestExtractTags(t *testing.T) {
+	provider := &Provider{ID: "test", Categories: []string{"Space"}}
+	curIndex := createIndexWithID("")
+	curIndex.addToView([]*Content{
+		&Content{ID: "0", Title: "Rocket launch delayed", Excerpt: "The launch was delayed by the weather"},
+		&Content{ID: "1", Title: "Rocket launch tonight", Excerpt: "Watch the launch"}})
+
+	untagged := &Content{ID: "2", Title: "Rocket launch reveals comet", Excerpt: "The comet was visible after the launch", Tags: []string{"Space"}}
+	tagged := &Content{ID: "3", Title: "Astronomers observe the sky", Tags: []string{"Astronomy", "Space"}}
+	extractTags(2, provider, curIndex, []*Content{untagged, tagged})
+
+	if !reflect.DeepEqual(untagged.GeneratedTags, []string{"comet", "launch reveals"}) {
+		t.Errorf("Expected tags comet and launch reveals, but got %q", untagged.GeneratedTags)
+	}
+	if !reflect.DeepEqual(untagged.Tags, []string{"comet", "launch reveals", "Space"}) {
+		t.Errorf("Expected generated tags to be added, but got %v", untagged.Tags)
+	}
+	if len(tagged.GeneratedTags) != 0 || len(tagged.Tags) != 2 {
+		t.Errorf("Expected no tags to be extracted from tagged content, but got %v", tagged.Tags)
+	}
+}
+
+func TestExtractTagsDisabled(t *testing.T) {
+	c := &Content{ID: "0", Title: "Rocket launch reveals comet"}
+	extractTags(0, &Provider{ID: "test"}, createIndexWithID(""), []*Content{c})
+	if len(c.Tags) != 0 {
+		t.Errorf("Expected no tags, but got %v", c.Tags)
+	}
+}
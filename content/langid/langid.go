@@ -0,0 +1,201 @@
+// Package langid identifies the language of short texts, such as the title and
+// excerpt of content, using character trigram profiles of the most frequent
+// trigrams of each language. Languages written in a script of their own are
+// identified by their script alone.
+package langid
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// Number of trigrams of a text needed for a fully confident identification,
+// shorter texts reduce the confidence accordingly
+const minTrigrams = 40
+
+// Factor by which the score of languages hinted at (e.g. by the URL of the
+// content) is increased
+const hintBoost = 1.15
+
+// Confidence of a language identified by hints only
+const hintConfidence = 0.25
+
+// Languages identified by the script they are written in
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Arabic, "ar"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// Languages implied by country code top-level domains
+var domainLanguages = map[string]string{
+	"at": "de", "br": "pt", "cn": "zh", "de": "de", "es": "es", "fr": "fr", "gr": "el",
+	"il": "he", "it": "it", "jp": "ja", "kr": "ko", "mx": "es", "nl": "nl", "pl": "pl",
+	"pt": "pt", "ru": "ru", "se": "sv", "th": "th", "tw": "zh", "ua": "uk", "uk": "en",
+	"us": "en", "au": "en", "nz": "en", "ie": "en",
+}
+
+// Supported returns true if the language (an ISO 639-1 code) can be identified
+func Supported(language string) bool {
+	if _, ok := profiles[language]; ok {
+		return true
+	}
+	for _, s := range scriptLanguages {
+		if s.language == language {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect returns the language of the text as ISO 639-1 code, along with the
+// confidence of the identification between 0 and 1. Hinted languages are
+// preferred if the text is ambiguous, and returned with low confidence if the
+// text doesn't allow for an identification. An empty string is returned if the
+// language can't be identified.
+func Detect(text string, hints ...string) (string, float64) {
+	text = strings.ToLower(text)
+	if language, confidence := detectScript(text); language != "" {
+		return language, confidence
+	}
+
+	trigrams := extractTrigrams(text)
+	scores := make(map[string]float64)
+	for language, profile := range profiles {
+		score := 0.0
+		for _, t := range trigrams {
+			if rank, ok := profile[t]; ok {
+				score += 1 - float64(rank)/float64(2*len(profile))
+			}
+		}
+		scores[language] = score
+	}
+	for _, hint := range hints {
+		if _, ok := scores[hint]; ok {
+			scores[hint] *= hintBoost
+		}
+	}
+
+	best, second := "", ""
+	for language, score := range scores {
+		if best == "" || score > scores[best] || (score == scores[best] && language < best) {
+			best, second = language, best
+		} else if second == "" || score > scores[second] || (score == scores[second] && language < second) {
+			second = language
+		}
+	}
+	if best == "" || scores[best] == 0 {
+		if len(hints) > 0 && Supported(hints[0]) {
+			return hints[0], hintConfidence
+		}
+		return "", 0
+	}
+
+	confidence := (scores[best] - scores[second]) / scores[best]
+	if len(trigrams) < minTrigrams {
+		confidence *= float64(len(trigrams)) / minTrigrams
+	}
+	return best, confidence
+}
+
+// detectScript returns the language of text written in a script used by a
+// single language only. Japanese texts mix kana and Han characters.
+func detectScript(text string) (string, float64) {
+	letters := 0
+	counts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.script, r) {
+				counts[s.language]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return "", 0
+	}
+
+	if counts["ja"] > 0 && counts["ja"]+counts["zh"] > letters/2 {
+		return "ja", 1
+	}
+	for language, count := range counts {
+		if count > letters/2 {
+			return language, 1
+		}
+	}
+	return "", 0
+}
+
+// extractTrigrams returns the character trigrams of the words of the text,
+// padded with an underscore marking the beginning and end of a word
+func extractTrigrams(text string) []string {
+	trigrams := make([]string, 0)
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		runes := []rune("_" + strings.Trim(word, "'") + "_")
+		for i := 0; i+3 <= len(runes); i++ {
+			trigrams = append(trigrams, string(runes[i:i+3]))
+		}
+	}
+	return trigrams
+}
+
+// HintsFromURL returns the languages implied by the URL: a language code as
+// first path segment (e.g. /de/ or /en-us/) or subdomain (e.g. fr.example.com),
+// and the country code top-level domain.
+func HintsFromURL(rawURL string) []string {
+	hints := make([]string, 0)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return hints
+	}
+
+	segments := strings.Split(strings.TrimPrefix(path.Clean("/"+u.Path), "/"), "/")
+	if language := languageOfCode(segments[0]); language != "" {
+		hints = append(hints, language)
+	}
+
+	labels := strings.Split(strings.ToLower(u.Hostname()), ".")
+	if len(labels) > 2 {
+		if language := languageOfCode(labels[0]); language != "" {
+			hints = append(hints, language)
+		}
+	}
+	if language, ok := domainLanguages[labels[len(labels)-1]]; ok {
+		hints = append(hints, language)
+	}
+	return hints
+}
+
+// languageOfCode returns the supported language of a language code or locale
+// (e.g. de, en-us or pt_BR), if any
+func languageOfCode(code string) string {
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		if len(code)-i != 3 {
+			return ""
+		}
+		code = code[:i]
+	}
+	if len(code) != 2 || !Supported(code) {
+		return ""
+	}
+	return code
+}
@@ -0,0 +1,83 @@
+package langid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := map[string]string{
+		"Why the housing market is cooling faster than expected":                                          "en",
+		"Warum der Wohnungsmarkt schneller abkühlt als erwartet":                                          "de",
+		"Pourquoi le marché immobilier se refroidit plus vite que prévu":                                  "fr",
+		"Por qué el mercado de la vivienda se enfría más rápido de lo esperado":                           "es",
+		"Perché il mercato immobiliare si sta raffreddando più in fretta del previsto":                    "it",
+		"Por que o mercado imobiliário está a arrefecer mais depressa do que o esperado":                  "pt",
+		"Waarom de woningmarkt sneller afkoelt dan verwacht":                                              "nl",
+		"Dlaczego rynek mieszkaniowy schładza się szybciej, niż oczekiwano":                               "pl",
+		"Därför kyls bostadsmarknaden av snabbare än väntat":                                              "sv",
+		"Почему рынок жилья остывает быстрее, чем ожидалось":                                              "ru",
+		"Чому ринок житла охолоджується швидше, ніж очікувалося":                                          "uk",
+		"住宅市場の冷え込みが予想より早い理由":                                                                              "ja",
+		"为什么房地产市场降温速度快于预期":                                                                                "zh",
+		"주택 시장이 예상보다 빠르게 냉각되는 이유":                                                                         "ko",
+		"Γιατί η αγορά κατοικίας ψύχεται ταχύτερα από το αναμενόμενο":                                     "el",
+		"The president met with leaders of both parties on Thursday to discuss the budget deal":           "en",
+		"Der Präsident traf sich am Donnerstag mit den Vorsitzenden beider Parteien":                      "de",
+		"Le président a rencontré jeudi les dirigeants des deux partis pour discuter du budget":           "fr",
+		"El presidente se reunió el jueves con los líderes de ambos partidos para hablar del presupuesto": "es",
+	}
+	for text, want := range tests {
+		if got, confidence := Detect(text); got != want {
+			t.Errorf("Expected %v for %q, but got %v (confidence %v)", want, text, got, confidence)
+		}
+	}
+}
+
+func TestDetectConfidence(t *testing.T) {
+	_, long := Detect("The president met with leaders of both parties on Thursday to discuss the budget deal")
+	_, short := Detect("Budget deal")
+	if long <= short {
+		t.Errorf("Expected longer text to be identified with more confidence, but got %v and %v", long, short)
+	}
+	if language, confidence := Detect("1234 !?"); language != "" || confidence != 0 {
+		t.Errorf("Expected no language for text without letters, but got %v (confidence %v)", language, confidence)
+	}
+}
+
+func TestDetectUsesHints(t *testing.T) {
+	if language, confidence := Detect("2:1", "de"); language != "de" || confidence != hintConfidence {
+		t.Errorf("Expected hinted language de, but got %v (confidence %v)", language, confidence)
+	}
+	if language, _ := Detect("Warum der Wohnungsmarkt schneller abkühlt als erwartet", "fr"); language != "de" {
+		t.Errorf("Expected hint to be overruled by text, but got %v", language)
+	}
+}
+
+func TestHintsFromURL(t *testing.T) {
+	tests := map[string][]string{
+		"https://www.spiegel.de/wirtschaft/article.html": []string{"de"},
+		"https://www.example.com/fr-fr/news/article":     []string{"fr"},
+		"https://es.example.com/noticias/article":        []string{"es"},
+		"https://www.example.com/business/article":       []string{},
+		"https://www.example.com/us/article":             []string{},
+		"https://it.example.com/de/article":              []string{"de", "it"},
+		"not a url":                                      []string{},
+	}
+	for u, want := range tests {
+		if got := HintsFromURL(u); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected hints %v for %v, but got %v", want, u, got)
+		}
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for _, language := range []string{"en", "de", "ja", "zh"} {
+		if !Supported(language) {
+			t.Errorf("Expected %v to be supported", language)
+		}
+	}
+	if Supported("xx") {
+		t.Error("Expected xx not to be supported")
+	}
+}
@@ -0,0 +1,186 @@
+package langid
+
+import "strings"
+
+// Profiles of the 300 most frequent trigrams of news articles in each language,
+// most frequent first
+var profileTrigrams = map[string]string{
+	"de": "en_ ie_ er_ _di die _de sch ten der nd_ und den _un ein _ha _da _si das nde ste " +
+		"_ei ch_ ich in_ ine rde _be _er _in _vo _wi ben che eit es_ gen hre sie _an _ge " +
+		"_st _wa _we _zu as_ ens ren sen te_ _me abe ass ere ers hat hen men rei rt_ ss_ " +
+		"_se _ve an_ and at_ ber cht em_ ind ist nge sse ung ver _al _au _ih _im _sc als " +
+		"auf bes de_ des ege ent ert fen ier ihr ite le_ lle ls_ ne_ nen nsc on_ sei sta " +
+		"ter tte zu_ _is _na _re _sa ach ag_ agt ahl ank aus cha era erh ern ger gte hab " +
+		"haf her ht_ ien ige im_ it_ len ler nac ng_ nte or_ ran reg rsc rst sag st_ tag " +
+		"uf_ von vor wer _ab _en _es _he _ja _ka _la _le _ma _mi _mo _ni _nä _so _ta _ze " +
+		"aft ahr ale ebe eck ehr ei_ erd erl for ft_ gef ges gt_ hei jah ken kra leb ll_ " +
+		"man meh mit nal ns_ och rer run ser sic tie tig tre um_ use wah zen äch _ak _bi " +
+		"_br _bu _dr _fa _fe _fo _fr _fü _gr _ho _hä _kr _ne _ob _pr _ri _sp _tr _wu _üb " +
+		"akt ali all ang ann anz ark art ate att be_ beh bei bun chi chl chn chs ckt dec " +
+		"dem ehm ehö eib eid eis el_ elt end ene erk eru esc ess est eue ewo fei ffe fäl " +
+		"ge_ gie han he_ hl_ hme hne hoc hr_ hst häl hör ibe ig_ igt ill is_ isi iss iti " +
+		"itt kan kt_ kti lan lic lie lis lt_ län mei mil mon nat ndi neh nem ner neu nic",
+	"en": "_th the he_ _to ed_ _in _of and ng_ to_ _an ing nd_ es_ of_ tha at_ in_ _ha hat " +
+		"re_ _a_ _wh ent er_ st_ _co _st _wi rs_ _be _re ear est _fo are as_ for is_ ld_ " +
+		"nt_ or_ res se_ _fi _ho _is _mo _ne _wa ate en_ ill ll_ ls_ on_ ove ree ter th_ " +
+		"ver _hi _it _li _on _pr _sa _wo an_ hou ion it_ le_ ore sto str tor ts_ ve_ _ar " +
+		"_de _di _ex _he _sh aid al_ als ase com din dis een ere eve fir han har her id_ " +
+		"ime ir_ ist lar ly_ me_ men oul oun ous pro sai ted uld und wil _ac _by _ca _fa " +
+		"_la _no _pe _po _ra _sc _si _we ad_ ain arl ars ati ave ay_ bee by_ ch_ day des " +
+		"ds_ eas ect eir ele ers ext ffi fic had has hav hea hei ici ies igh int ith mon " +
+		"mor nds ne_ ns_ nti off old one ong ons ont our pec red rep rge ry_ sea sin te_ " +
+		"tho tim tin tre ut_ ves was whe whi wit wou _bu _ce _ch _cl _da _do _fl _fr _im " +
+		"_lo _ma _ou _ri _su _ta _te _ti _va _ye _yo acc ade age ake ama ame ani ar_ arc " +
+		"arg ast be_ but ce_ cen che chi cia cie cis cou cov cre cte dat de_ dec den eci " +
+		"eet eme eop epa era erc ese ess et_ ew_ exp ey_ fou fro ger ges gh_ hey hic hig " +
+		"his hit ho_ hos ial ic_ ice ich ide imp ina inv irs isc ise isi iti ity ive ke_ " +
+		"lea lic liv lli los mpa nal ndi new nex nin not nte nth ntr nts nve oll omp oo_",
+	"es": "_de os_ es_ _la de_ as_ _qu que ue_ el_ _el _lo la_ do_ en_ los las _en _es _se " +
+		"_co est _y_ ado on_ res _ha _un ent ero _in _po _pr nte _di des ien or_ ron se_ " +
+		"an_ ant ica ier na_ ra_ sta to_ _a_ _má _ti dad ida les más per por ás_ _pa _pe " +
+		"_su cio con del ido nta pre ran tar tes tie una ía_ _ca _ci _ma _me _tr aci al_ " +
+		"ara cad ció esc inv ion lo_ mil nes no_ one ore par te_ tor tra uer un_ ón_ _al " +
+		"_au _mi _nu _to _vi ade and ar_ cen cie cos da_ dij dis dor eci end er_ esp ias " +
+		"ici iga ión men ndi nto nve ora rid ro_ rte rá_ scu seg su_ ta_ ños _an _ce _du " +
+		"_fu _no _re _va _ve aba ada ari ará año bri ca_ cin co_ com cub did die dur egu " +
+		"ele emp ene era ert esa fic fue ga_ gad ha_ ile ina io_ ipo ist jer lic mar mer " +
+		"mo_ ne_ nos nue nun ona ori po_ pro pró ria rim spe sti str stá tan ten tig tos " +
+		"ura ves _ac _af _añ _do _em _fa _fi _gr _ho _ll _pu _sa _si _so _te abí ace acu " +
+		"ad_ ale all alo alt ami anc ani are aro art asi ato aum aut ban bie bli bol bía " +
+		"cal cas cci cer cor cue das dec dem dic drá ect eda egú ejo ema epa erc erd eri " +
+		"ern ers ese eva fin gui gul gún hab hac han iad ico idi ie_ ije ijo ili ime imo " +
+		"inc int isp ita ió_ jo_ jor lac lar lec lla lle llo lto man mas mej mes mie mos",
+	"fr": "es_ _de nt_ _le _qu le_ ent les de_ que des ont _pr re_ ue_ _la eur it_ la_ _pl " +
+		"er_ ns_ rs_ _et _on et_ lle plu _co _en _l' ion lus ne_ ur_ _pa _se _un _à_ ait " +
+		"is_ men on_ our par urs us_ ill iqu ort te_ tio _a_ _dé _ma _po _so ant ien ons " +
+		"qui son ter tre _au _ce _di _fa _fo _in ans ati dan ell en_ ouv por qu' rai se_ " +
+		"té_ ui_ un_ une és_ _ap _av _da _no _su _tr _vi _ét aie ce_ che com cou déc eme " +
+		"ers il_ pre pro qué rem rte tes ts_ uve ux_ ué_ ver été _ca _d' _gr _mi _pe ale " +
+		"app ard aut aux ble cha end est ie_ ièr leu mil ndi nou nte ntr nts ois omm per " +
+		"pou ran res tro tés ère ête 'es 'il _an _ch _du _fi _jo _mo _re _sa _vo acc ais " +
+		"and ann ar_ as_ au_ ava ave cen con dat du_ emi emp era ert fai for hai heu ibl " +
+		"ier in_ ire ist ita ité jou l'é lli lon mai moi ndr née oir onn ppe ppo pré rch " +
+		"rdi ren rep rit roc rop rt_ rêt seu sur tan tem u'i ues ure ut_ vai vot éco ée_ " +
+		"ées 'an 'el 'en 'un 'él _ac _ba _ci _ha _il _ju _lo _n' _ne _pu _ré _ta _te _to " +
+		"_va _éc _él ain air al_ ama an_ anc ani are aré aug bli bre cco ces ché cin cla " +
+		"cor cri cti dep dev die diq dis dit dra eau ec_ eil elo epr epu erc esp ess evr " +
+		"fin fon gme gra her ice ies ieu ima ina ind ine inv ir_ ise iti iva l'a l'e lar",
+	"it": "to_ no_ _ch _di che he_ _de ann le_ _ha di_ la_ per re_ _in nno ti_ ato ent _e_ " +
+		"ell _co _i_ _la _pr gli _pi _un han li_ ni_ si_ ta_ _pe del men sta _il _ne est " +
+		"il_ iù_ lle più ra_ tto _al _da _ma _qu _st _è_ er_ ha_ nti ori ro_ tor _a_ _le " +
+		"_ri _sc are era ere ett ima in_ lla ma_ nte que ri_ tà_ _an _ca _do _mi _po _se " +
+		"_so _su _vi ali att chi det do_ end ess ia_ ica igl ion ita mig na_ nto oni ono " +
+		"ore ort par pro ran rim se_ sto tar tra un_ _au _ci _fa _fi _fo _pa _re _sa _te " +
+		"_tr ai_ ale ame and ati ca_ cat com con da_ eci ei_ el_ ert ie_ ino int ior ito " +
+		"ità lo_ ndo nel nni ora ost po_ pri rar rit rà_ sco ser son spe sse tat te_ ter " +
+		"una ver vit _ba _gl _l' _me _no _nu _or _si _ti _vo aga al_ all alo ant ard bbe " +
+		"be_ bil cen ci_ cie cin cop cor cos dal de_ dis dov ebb egg erc ero ers fin for " +
+		"gio iat ien ili ina inv isp l'a lia lio lor me_ mo_ nde ndi ne_ ome on_ ope opp " +
+		"por ppo pre qua rdi reb res rno rta rti rto sa_ so_ ssi str ten tit tte tti uel " +
+		"ume uto zia zio _ab _ag _ai _av _ce _es _fe _gi _gr _lo _sp _ta _tu _va _ve abi " +
+		"acc agl aia ala amb anc ani ape ara ari ars art ass aum aut azi azz ber cam cer " +
+		"cis cit col dat dec dei dia dra eco eda ego ele emp enz eri ern erà ese fan gge",
+	"nl": "en_ de_ _de _he et_ an_ het _da at_ gen ten _be te_ ver _en _va _di _ee _ge _ve " +
+		"dat ers ing _in _te aar een er_ ie_ in_ nde oor van den ste die men or_ _st _vo " +
+		"_we _zi aan and ar_ ens ere ond ren sch _me _on _op _wa _ze eer is_ nd_ ng_ nge " +
+		"ven _ha _is al_ eef ege len rde rs_ st_ _aa _la _ni _re ate der eft ent ft_ hee " +
+		"lge maa nie ns_ op_ rij ter _do _du _ka _le _ma _na ard bbe ben dan dri ebb eid " +
+		"eke ekt ele eme end erd erk ete heb ier ij_ ijv ist jve kt_ lan lle mee olg ort " +
+		"rd_ reg rin rst str tro vol voo waa ze_ zen zij _al _gr _ho _ja _mi _pa _pr _sc " +
+		"_wi _za aat ang are bed ber bes ch_ cha dag dek doo dui edr eri erl erw erz est " +
+		"eur ewo gd_ gem ger gez had har hoo ich ide ijn ind it_ ite iti ize jn_ kan ken " +
+		"ker kte le_ lij lis min ndi nne nse ntd nte oek oen og_ ont ord re_ rei rie roo " +
+		"rt_ rzo sen tde tie tor uiz uwe we_ wer wet win won zic zoe _bl _br _dr _er _fi " +
+		"_hu _je _jo _ki _ko _mo _om _ov _ro _vi _wo _zu aak aal ach ad_ ag_ akt ale ame " +
+		"ant art ati bel bij bli cen che cht del dig dit doe dt_ egg ei_ ema eng enw erb " +
+		"esl euw eve ezi fin gek gev gew gge gri gro gt_ hap her hti hun iek iet ieu ig_ " +
+		"ijf ijg ijk ina ini ink jaa jar je_ jf_ jou kin kri laa lat lee lev lin loo ls_",
+	"pl": "_po dzi ie_ _na _pr _w_ ch_ wie zie owa prz _i_ na_ nie rze rzy szy ied ny_ że_ " +
+		"_że li_ ych ym_ zy_ _do _ni _wy cze edz ier ost owi się _ja _je _kt _mi _za by_ " +
+		"jak któ mie pow sta trz tór _ki _ro _si _st _te _wi _z_ ach ać_ ej_ iał ię_ kie " +
+		"naj nik str szc tow zcz ze_ zyc _dr _fi _ma _od _sz ale ali at_ ał_ bli cy_ czn " +
+		"ego ek_ esi est esz go_ icz ies ika ięc jes ją_ kow kry le_ lic ma_ nyc pie pro " +
+		"ują wać wał wię zia zos ło_ _de _dz _la _os _pi _sp _ty _we _ży aby ak_ aki ały " +
+		"ce_ cen ci_ cie ciu czy do_ dłu ent erw ez_ gu_ ia_ iej ili iu_ iąg ięk ka_ kan " +
+		"ksz lat nad oce odz ora owy por pra rac ran roc roz ry_ ryw sią spó st_ sto szk " +
+		"tor wal wan we_ wia wy_ wyb yci zez zny zym zyn óry ów_ ęks ła_ ły_ _a_ _ab _ak " +
+		"_al _ap _ba _bę _ci _co _go _ka _ko _kr _mo _no _op _ra _rz _se _sk _sw _są _tr " +
+		"_uc _ul _zb _zd _zo _zw aci ad_ ada adz ajb ajw akc an_ ane ann apo ard art asz " +
+		"atu auk awi az_ ała ało ba_ bor będ cho cią co_ czo ców dac des dkr dos dra dy_ " +
+		"ed_ edn edy edł ejm fin fir got gry hor iec iel ien ilk in_ ina irm ist iąc ięt " +
+		"iż_ jed jej ji_ kcj kil ko_ kra lar lep liż lka lny my_ nal nau ne_ neg nia nio " +
+		"niż nny no_ now ną_ obi oby od_ odk oje oko ona ony opr ore oro oso osz oto owc",
+	"pt": "as_ _de de_ _qu os_ que ue_ do_ es_ _a_ ar_ est am_ _co _o_ res _do _e_ _es _ma " +
+		"_se ent ram _as _pr ado to_ ão_ _di _no das is_ sta _da _in _um ais ara ida mai " +
+		"no_ _pe _te dis ore ra_ tar _na _po ade com con da_ em_ er_ ia_ men nos par ro_ " +
+		"se_ ver _fe _mi _os _pa _va al_ dad dor ist lha ma_ nte nve um_ uma _an _ca _fo " +
+		"_me _tr ada des era esc har ica inv ira iss nto or_ ort pre ser sse sti te_ tem " +
+		"ões _au _el _em _nã ano ant cad co_ dos egu eir ele emp gad ido ilh mil ndi ndo " +
+		"não ora pel pro rid rte sa_ sas sco str ta_ tes tra ves ção _ac _al _ci _fa _fi " +
+		"_nu _so _ve _vi _é_ ai_ ame are aze cen eci ela elh ern ert esa eu_ for hor ias " +
+		"ico iga ime inu la_ lho mar mei min mo_ na_ nas nic nta nti num om_ ou_ per por " +
+		"pos pri ria rim rno rta seg ssa stá tas ter tig tin tos tro tá_ uas und va_ vai " +
+		"çõe _af _ao _ce _cr _câ _du _en _go _ho _há _re _sa _su _ta _to _vo _à_ aba abi " +
+		"aco aio alh anc and ard ato aum aut ava açã açõ bal ber cas cia cid cie cin cio " +
+		"cis cob cor cos cri câm dec dep der dev dia did el_ elo epa ero erá esp ess eva " +
+		"eve ez_ faz fei fin foi gia gov gul gun ham hos há_ iam imo ina inc ind ine ing " +
+		"ini io_ ior ipa isp ito lar lev lo_ lor mas mel mes mos mpe mpo mpr mun nal nci",
+	"ru": "_по то_ _в_ _и_ _пр ей_ что _ко _чт ся_ тор _на ени ли_ тся ый_ ет_ ова ото тра " +
+		"ть_ _бо _го _до _за _сл бол го_ ем_ на_ но_ ом_ стр тел ые_ _де _ка _не дал ие_ " +
+		"кот льн оры сле _вы _жи _он _от _ре _ск _ст _те _че али ать ают его их_ ия_ ко_ " +
+		"ком лед ник ния оле оль _из _ле _но _об ам_ ани аст да_ еду ее_ ель ент ии_ или " +
+		"как ки_ ние ные ое_ ост пер пос пре при ран рый сто тов чем ыва _бы _вс _ис _од " +
+		"_ра _со _уч _эт ада ает ак_ ал_ ала ане ате ах_ аше вал ват вит вое все гор дел " +
+		"доб ела ере еск ест еся жил из_ ина ист ите итс иче кры ла_ лее лет лис ло_ луч " +
+		"льш люд мес не_ нен нов ное обе ов_ овы ого одо она оро от_ оце по_ про ра_ рад " +
+		"реж роц скр сло ссл ста ств сь_ ти_ цен чен чес ше_ ых_ ьны ьше это ют_ ютс яви " +
+		"ям_ _ак _ба _бу _ва _ве _во _да _к_ _кр _ли _лю _ме _ми _ни _пе _са _св _се _си " +
+		"_то _тр _ты _ул _фи аво аза акц але аль аме ан_ анд аяв бир буд бы_ вам ваю вет " +
+		"вил во_ вор вый выс гда гов гот дат даю дет ди_ дно до_ дов дол дра дую едо еле " +
+		"ели ен_ ень ер_ ерв есс ете ече же_ жиз за_ зал зая зыв ида изн ий_ ико ило иль " +
+		"исс ись ить ици иям ка_ каз ков ког кол кру кто кци ле_ лей лек лен лиц лов лос " +
+		"льк мог мпа мы_ нал нат ней нес ни_ ний нто ны_ ный ных нь_ обн ово огд одн оей",
+	"sv": "en_ tt_ er_ att _at _de de_ _fö et_ för ar_ _ha _oc ch_ och rna na_ _va ta_ _av " +
+		"_i_ ade det ter _en _st om_ sta ör_ _ko _si av_ era gen ra_ re_ som upp är_ _på " +
+		"_so _up age and ern har kom mer omm or_ på_ ste _in _me _ti ale arn den ent nde " +
+		"nen ska _fo _re _sa _sk _vi as_ da_ der for ger gt_ ing ler ll_ nge nta sen tal " +
+		"te_ örs _be _fi _ka _kr _än _är are ats dag eta isk kar lag lan mme nar ort rde " +
+		"reg ret rsk rst sig sko tor ts_ val var ver än_ äng _dr _et _ho _hä _hå _la _li " +
+		"_mä _må _nä _pr _ri _tr _vä _år abb ad_ bba dig dra edd ela ett fin ga_ get had " +
+		"ig_ iga igt ill in_ ina int ist kan la_ let lle län man med men nal nda ndi nis " +
+		"ns_ nte ors rat red rt_ sa_ sin sto stö ten tig til tis tra tör änn änt äst åna " +
+		"örb öre öve _du _ef _fa _fr _gö _hö _lä _mi _ny _om _sä _te _tu _ök _öv aft ag_ " +
+		"all als an_ ana ans ara art ast at_ bar ber bes cen ckt dar dde del des dju dor " +
+		"du_ eft ege egn ele ens ere eri ers es_ ess ete fte fti ghe gjo gon het hon här " +
+		"hår hög ier igh ilj ind inv ise iti jor juk jur ka_ kad kor kra kti lev li_ lig " +
+		"lis liv lor ls_ mar mil min mma myn män mån nad nd_ nin nni nsi nt_ när oce on_ " +
+		"one opp orn pma ppm ppo ppt pro ptä rab rad raf ram ran rar rbe rda rin roc rts",
+	"uk": "_на _по _що ся_ _до _за ий_ ли_ що_ _ві _як на_ _ви ати ти_ ть_ ів_ ків тьс ься " +
+		"іль _в_ _пр _ро _і_ али вал ого _ко _не від го_ их_ она пер ува ше_ ють _де _мі " +
+		"ві_ ень ере ла_ ми_ нь_ оби оро та_ тор тра іст _во _ск _та алі ают ає_ біл ват " +
+		"вон до_ за_ зро или ися ки_ кра льн льш му_ над най не_ ни_ ник них ом_ ому ост " +
+		"роб рок ста тим ії_ _гр _жи _зр _ма _ні _пе _пі _рі _св _ти _у_ _це ав_ ад_ ала " +
+		"але би_ бит вий вор дал ди_ дос ей_ ила ити итт ить ка_ ков ком кри ку_ кі_ кіл " +
+		"лід міс ний ння но_ ня_ ні_ ова ови окі оло они орі под пор про ри_ роз слі сто " +
+		"стр сув тис то_ ті_ уть ці_ чі_ ьни ьши яви як_ які ія_ _ак _ал _ба _бу _бі _ва " +
+		"_вп _го _ді _з' _з_ _зб _зн _ка _кр _кі _лю _лі _но _од _ра _сл _сп _ст _те _тр " +
+		"_ус _фі _хв _ще _із ади ажд аза ази айб акц аль аме ами ан_ анд анк ані аст атк " +
+		"ах_ ашо аяв бор буд вам вар вен виб вид вил вис він гол гот гри дав дат де_ дер " +
+		"дин дни дсо діл еле ене енн ент ерш жда жит з'я зак зал зая зум ибо ива ика икі " +
+		"ими иму ин_ ина исо иці иша йбі каз кан кий ко_ кол кує кці лад ле_ лен лиш лов " +
+		"лос льк люд лі_ лік ліс ман має мпа мут міл нал нас нат нов ном ніж ніх ові ода " +
+		"оди оді озу оки окр оли олі ома омп ори орт осл отк отр оту ою_ пан пок пон пос",
+}
+
+// profiles maps the trigrams of each language's profile to their rank
+var profiles = make(map[string]map[string]int)
+
+func init() {
+	for language, trigrams := range profileTrigrams {
+		profile := make(map[string]int)
+		for rank, t := range strings.Fields(trigrams) {
+			profile[t] = rank
+		}
+		profiles[language] = profile
+	}
+}
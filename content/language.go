@@ -0,0 +1,30 @@
+package content
+
+import "mozilla.org/crec/content/langid"
+
+// Minimum confidence of the language identified in the title and excerpt of
+// content without declared language for it to be used
+const minLanguageConfidence = 0.15
+
+// Minimum confidence of the language identified in the title and excerpt of
+// content for it to replace a contradicting declared language
+const minContradictionConfidence = 0.3
+
+// detectLanguage identifies the language of the content's title and excerpt,
+// taking hints from its URL into account. The identified language is used if
+// the content declares no language, or if it contradicts the declared language
+// with high confidence.
+func detectLanguage(c *Content) {
+	language, confidence := langid.Detect(c.Title+" "+c.Excerpt, langid.HintsFromURL(c.URL)...)
+	if language == "" {
+		return
+	}
+
+	declared := localeLanguage(c.Language)
+	if declared == "" && confidence >= minLanguageConfidence {
+		c.Language = language
+	} else if declared != "" && declared != language && langid.Supported(declared) &&
+		confidence >= minContradictionConfidence {
+		c.Language = language
+	}
+}
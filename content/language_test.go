@@ -0,0 +1,23 @@
+package content
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		content *Content
+		want    string
+	}{
+		{&Content{Title: "Scientists discover a new species of frog", Excerpt: "The animal was found living high in the trees"}, "en"},
+		{&Content{Title: "Wissenschaftler entdecken eine neue Froschart", Excerpt: "Das Tier lebte hoch oben in den Bäumen", Language: "en"}, "de"},
+		{&Content{Title: "Scientists discover a new species of frog", Language: "en-US"}, "en-US"},
+		{&Content{Title: "Scientists discover a new species of frog", Excerpt: "The animal was found living high in the trees", Language: "da"}, "da"},
+		{&Content{Title: "2:1", URL: "https://www.example.com/de/sport/article.html"}, "de"},
+		{&Content{Title: "2:1"}, ""},
+	}
+	for _, test := range tests {
+		detectLanguage(test.content)
+		if test.content.Language != test.want {
+			t.Errorf("Expected language %v for %q, but got %v", test.want, test.content.Title, test.content.Language)
+		}
+	}
+}
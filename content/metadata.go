@@ -0,0 +1,449 @@
+package content
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"mozilla.org/crec/content/processor"
+)
+
+// Maximum number of pages whose metadata is cached, the least recently used
+// pages are evicted first
+const metadataCacheLimit = 10000
+
+// Time after which the metadata of pages which couldn't be fetched is fetched again
+const metadataRetryInterval = time.Hour
+
+// Maximum size of fetched pages, the remainder is ignored
+const maxPageSize = 5 * 1024 * 1024
+
+// Types of schema.org JSON-LD objects describing articles
+var articleTypes = map[string]bool{"Article": true, "NewsArticle": true, "ReportageNewsArticle": true,
+	"AnalysisNewsArticle": true, "BlogPosting": true, "Report": true}
+
+// pageMetadata holds the metadata of an article page, found in its Open Graph
+// tags, Twitter card and JSON-LD
+type pageMetadata struct {
+	canonical string
+	image     string
+	author    string
+	published *Timestamp
+	tags      []string
+	language  string
+
+	fetched time.Time
+	failed  bool
+}
+
+// metadataCache caches the metadata of pages, shared by all providers, so each
+// page is only fetched once
+type metadataCache struct {
+	limit int
+	pages map[string]*list.Element
+	// URLs of the cached pages, most recently used first
+	order *list.List
+	mux   sync.Mutex
+}
+
+func newMetadataCache(limit int) *metadataCache {
+	return &metadataCache{limit: limit, pages: make(map[string]*list.Element), order: list.New()}
+}
+
+type cachedPage struct {
+	url      string
+	metadata *pageMetadata
+}
+
+var metadata = newMetadataCache(metadataCacheLimit)
+
+// enrichContent fills in the missing image, author, publication date, tags and
+// language of the provider's content using the metadata of the linked pages.
+// Pages of content with complete metadata aren't fetched. The canonical URLs of
+// the pages, if known, replace the URLs of the content (and IDs derived from them).
+func enrichContent(config Config, provider *Provider, client *http.Client, polite *politeness, content []*Content) []*Content {
+	for _, c := range content {
+		var m *pageMetadata
+		if c.Image != "" && c.Author != "" && c.Published != nil && c.Language != "" &&
+			len(c.Tags) > len(provider.Categories) {
+			if m = metadata.lookup(c.URL); m == nil {
+				continue
+			}
+		} else {
+			m = metadata.get(config, provider, client, polite, c.URL)
+		}
+
+		if m.canonical != "" {
+			canonical := canonicalizeURL(m.canonical, provider.GetTrackingParams())
+			if c.ID == c.URL {
+				c.ID = canonical
+			}
+			c.URL = canonical
+		}
+		if c.Image == "" {
+			c.Image = m.image
+		}
+		if c.Author == "" {
+			c.Author = m.author
+		}
+		if c.Published == nil {
+			c.Published = m.published
+		}
+		if c.Language == "" {
+			c.Language = m.language
+		}
+		if len(c.Tags) == len(provider.Categories) && len(m.tags) > 0 {
+			c.Tags = append(append([]string{}, m.tags...), provider.Categories...)
+			maybeAppendExplanation(c)
+		}
+	}
+	return dedupeContent(content)
+}
+
+// lookup returns the cached metadata of the page, nil if not cached
+func (c *metadataCache) lookup(pageURL string) *pageMetadata {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	e, ok := c.pages[pageURL]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cachedPage).metadata
+}
+
+// get returns the metadata of the page, fetching it if not cached
+func (c *metadataCache) get(config Config, provider *Provider, client *http.Client, polite *politeness, pageURL string) *pageMetadata {
+	m := c.lookup(pageURL)
+	if m != nil && (!m.failed || time.Since(m.fetched) < metadataRetryInterval) {
+		return m
+	}
+
+	m, err := fetchMetadata(config, provider, client, polite, pageURL)
+	if err != nil {
+		log.Printf("Failed to fetch metadata of %v for provider %v: %v", pageURL, provider.ID, err)
+		m = &pageMetadata{failed: true}
+	}
+	m.fetched = time.Now()
+
+	c.add(pageURL, m)
+	return m
+}
+
+// add caches the metadata of the page, evicting the least recently used page if
+// the cache is full
+func (c *metadataCache) add(pageURL string, m *pageMetadata) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if e, ok := c.pages[pageURL]; ok {
+		e.Value.(*cachedPage).metadata = m
+		c.order.MoveToFront(e)
+		return
+	}
+	if len(c.pages) >= c.limit {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.pages, oldest.Value.(*cachedPage).url)
+	}
+	c.pages[pageURL] = c.order.PushFront(&cachedPage{url: pageURL, metadata: m})
+}
+
+func fetchMetadata(config Config, provider *Provider, client *http.Client, polite *politeness, pageURL string) (*pageMetadata, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &pageMetadata{}, nil
+	}
+	body, err := fetchPage(config, provider, client, polite, u)
+	if err == errDisallowedByRobots {
+		return &pageMetadata{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseMetadata(bytes.NewReader(body), pageURL)
+}
+
+// fetchPage returns the body of the page linked by the provider's content, once
+// it's the provider's turn to request it from its host (see politeness.await)
+func fetchPage(config Config, provider *Provider, client *http.Client, polite *politeness, u *url.URL) ([]byte, error) {
+	err := polite.await(config, provider, client, u)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Headers and credentials are only sent to the host of the provider's content
+	if contentURL, err := url.Parse(provider.ContentURL); err == nil && contentURL.Host == u.Host {
+		err = provider.prepareRequest(req)
+		if err != nil {
+			return nil, err
+		}
+	} else if provider.UserAgent != "" {
+		req.Header.Set("User-Agent", provider.UserAgent)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+}
+
+// pageFetcher fetches the pages linked by the provider's content for processors
+// (see processor.Context.Fetch) like pages fetched for their metadata. Failures
+// are recorded in the report. The metadata of fetched pages is cached, so pages
+// aren't fetched again when the content is enriched.
+type pageFetcher struct {
+	config   Config
+	provider *Provider
+	client   *http.Client
+	polite   *politeness
+	report   *Report
+}
+
+func newPageFetcher(config Config, provider *Provider, client *http.Client, polite *politeness, report *Report) *pageFetcher {
+	return &pageFetcher{config: config, provider: provider, client: client, polite: polite, report: report}
+}
+
+// fetcher returns the function fetching pages for processors, nil if pages can't be fetched
+func (f *pageFetcher) fetcher() func(string) ([]byte, error) {
+	if f == nil {
+		return nil
+	}
+	return f.fetch
+}
+
+func (f *pageFetcher) fetch(pageURL string) ([]byte, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		f.report.failPage(pageURL, err)
+		return nil, err
+	}
+	body, err := fetchPage(f.config, f.provider, f.client, f.polite, u)
+	m := &pageMetadata{}
+	if err == nil {
+		m, err = parseMetadata(bytes.NewReader(body), pageURL)
+	}
+	if err != nil && err != errDisallowedByRobots {
+		log.Printf("Failed to fetch page %v for provider %v: %v", pageURL, f.provider.ID, err)
+		m = &pageMetadata{failed: true}
+	}
+	m.fetched = time.Now()
+	metadata.add(pageURL, m)
+
+	if err != nil {
+		f.report.failPage(pageURL, err)
+		return nil, err
+	}
+	return body, nil
+}
+
+// parseMetadata parses the page's JSON-LD, Open Graph tags and Twitter card,
+// preferring JSON-LD if present
+func parseMetadata(r io.Reader, pageURL string) (*pageMetadata, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &pageMetadata{}
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var v interface{}
+		if json.Unmarshal([]byte(s.Text()), &v) == nil {
+			m.merge(parseJSONLD(v, pageURL))
+		}
+	})
+
+	meta := func(selector string) []string {
+		values := make([]string, 0)
+		doc.Find(selector).Each(func(_ int, s *goquery.Selection) {
+			if content := strings.TrimSpace(s.AttrOr("content", "")); content != "" {
+				values = append(values, content)
+			}
+		})
+		return values
+	}
+	og := &pageMetadata{
+		canonical: resolveCanonicalURL(pageURL, doc.Find(`link[rel="canonical"]`).AttrOr("href", ""),
+			firstValue(meta(`meta[property="og:url"]`))),
+		image: processor.PreferHTTPS(processor.ResolveImageURL(pageURL, firstValue(meta(`meta[property="og:image:secure_url"]`))),
+			processor.ResolveImageURL(pageURL, firstValue(meta(`meta[property="og:image"], meta[property="og:image:url"]`))),
+			processor.ResolveImageURL(pageURL, firstValue(meta(`meta[name="twitter:image"], meta[name="twitter:image:src"]`)))),
+		tags:     meta(`meta[property="article:tag"]`),
+		language: localeLanguage(firstValue(meta(`meta[property="og:locale"]`))),
+	}
+	for _, author := range meta(`meta[name="author"], meta[property="article:author"]`) {
+		// article:author often holds the URL of the author's profile
+		if !strings.HasPrefix(author, "http:") && !strings.HasPrefix(author, "https:") {
+			og.author = author
+			break
+		}
+	}
+	if t, err := ParseTimestamp(firstValue(meta(`meta[property="article:published_time"]`))); err == nil {
+		og.published = NewTimestamp(t)
+	}
+	if len(og.tags) == 0 {
+		og.tags = splitKeywords(firstValue(meta(`meta[name="news_keywords"], meta[name="keywords"]`)))
+	}
+	if og.language == "" {
+		og.language = localeLanguage(doc.Find("html").AttrOr("lang", ""))
+	}
+	m.merge(og)
+	return m, nil
+}
+
+// resolveCanonicalURL returns the first of the canonical URLs, resolved against
+// the URL of the page, which is an absolute HTTP(S) URL of an article on the same
+// site. Canonical URLs pointing to the home page or another site are ignored, as
+// some sites declare these for all of their pages.
+func resolveCanonicalURL(pageURL string, canonicals ...string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	for _, canonical := range canonicals {
+		u, err := base.Parse(strings.TrimSpace(canonical))
+		if err != nil || canonical == "" || !isValidURL(u.String()) {
+			continue
+		}
+		if u.Path == "" || u.Path == "/" || registrableDomain(u.Hostname()) != registrableDomain(base.Hostname()) {
+			continue
+		}
+		return u.String()
+	}
+	return ""
+}
+
+// merge fills in the fields of m missing a value
+func (m *pageMetadata) merge(other *pageMetadata) {
+	if m.canonical == "" {
+		m.canonical = other.canonical
+	}
+	if m.image == "" {
+		m.image = other.image
+	}
+	if m.author == "" {
+		m.author = other.author
+	}
+	if m.published == nil {
+		m.published = other.published
+	}
+	if len(m.tags) == 0 {
+		m.tags = other.tags
+	}
+	if m.language == "" {
+		m.language = other.language
+	}
+}
+
+// parseJSONLD returns the metadata of the first article found in the JSON-LD
+// value, which may be a single object, a list or a @graph
+func parseJSONLD(v interface{}, pageURL string) *pageMetadata {
+	m := &pageMetadata{}
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			m.merge(parseJSONLD(item, pageURL))
+		}
+	case map[string]interface{}:
+		if graph, ok := v["@graph"]; ok {
+			m.merge(parseJSONLD(graph, pageURL))
+		}
+		if !isArticle(v["@type"]) {
+			return m
+		}
+		m.merge(&pageMetadata{
+			image:    processor.ResolveImageURL(pageURL, firstValue(jsonLDValues(v["image"], "url"))),
+			author:   strings.Join(jsonLDValues(v["author"], "name"), ", "),
+			tags:     jsonLDKeywords(v["keywords"]),
+			language: localeLanguage(firstValue(jsonLDValues(v["inLanguage"], "alternateName"))),
+		})
+		if t, err := ParseTimestamp(firstValue(jsonLDValues(v["datePublished"], ""))); err == nil && m.published == nil {
+			m.published = NewTimestamp(t)
+		}
+	}
+	return m
+}
+
+func isArticle(t interface{}) bool {
+	for _, name := range jsonLDValues(t, "") {
+		if articleTypes[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonLDValues returns the strings of the JSON-LD property, which may be a
+// string, an object holding the string in the given key or a list of either
+func jsonLDValues(v interface{}, key string) []string {
+	values := make([]string, 0)
+	switch v := v.(type) {
+	case string:
+		if s := strings.TrimSpace(v); s != "" {
+			values = append(values, s)
+		}
+	case map[string]interface{}:
+		if key != "" {
+			values = append(values, jsonLDValues(v[key], "")...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			values = append(values, jsonLDValues(item, key)...)
+		}
+	}
+	return values
+}
+
+// jsonLDKeywords returns the keywords, declared as a list or a comma-separated string
+func jsonLDKeywords(v interface{}) []string {
+	keywords := make([]string, 0)
+	for _, s := range jsonLDValues(v, "") {
+		keywords = append(keywords, splitKeywords(s)...)
+	}
+	return keywords
+}
+
+func splitKeywords(s string) []string {
+	keywords := make([]string, 0)
+	for _, keyword := range strings.Split(s, ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
+
+// localeLanguage returns the language of the locale e.g. en for en_US or en-US
+func localeLanguage(locale string) string {
+	locale = strings.TrimSpace(locale)
+	if i := strings.IndexAny(locale, "_-"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(locale)
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
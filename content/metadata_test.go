@@ -0,0 +1,299 @@
+package content
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mozilla.org/crec/content/processor"
+)
+
+func TestParseMetadata(t *testing.T) {
+	page := `<html lang="de-AT"><head>
+		<meta property="og:image" content="/og.jpg">
+		<meta property="og:locale" content="en_US">
+		<meta name="author" content="OG Author">
+		<meta property="article:tag" content="og-tag">
+		<meta property="article:published_time" content="2017-09-17T13:00:00Z">
+		<script type="application/ld+json">{"@context": "http://schema.org", "@graph": [
+			{"@type": "WebSite", "name": "Example"},
+			{"@type": ["NewsArticle"], "author": [{"@type": "Person", "name": "A1"}, {"name": "A2"}],
+			 "datePublished": "2017-09-17T15:53:05+02:00", "keywords": "k1, k2", "image": {"url": "https://example.com/ld.jpg"}}
+		]}</script>
+		</head><body></body></html>`
+
+	m, err := parseMetadata(strings.NewReader(page), "https://example.com/articles/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.image != "https://example.com/ld.jpg" || m.author != "A1, A2" || m.language != "en" {
+		t.Errorf("Expected JSON-LD metadata, falling back to Open Graph, but got %v", m)
+	}
+	if !reflect.DeepEqual(m.tags, []string{"k1", "k2"}) {
+		t.Errorf("Expected JSON-LD keywords, but got %v", m.tags)
+	}
+	if m.published == nil || !m.published.Equal(time.Date(2017, 9, 17, 13, 53, 5, 0, time.UTC)) {
+		t.Errorf("Expected JSON-LD publication date, but got %v", m.published)
+	}
+
+	m, err = parseMetadata(strings.NewReader(`<html lang="de-AT"><head>
+		<meta name="twitter:image" content="//cdn.example.com/tw.jpg">
+		<meta property="article:author" content="https://example.com/authors/1">
+		<meta name="news_keywords" content="n1,n2">
+		</head></html>`), "http://example.com/articles/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.image != "http://cdn.example.com/tw.jpg" || m.author != "" || m.language != "de" || !reflect.DeepEqual(m.tags, []string{"n1", "n2"}) {
+		t.Errorf("Expected Twitter card and meta tags, but got %v", m)
+	}
+}
+
+func TestIngestEnrichesContentWithMetadata(t *testing.T) {
+	var pageRequests int32
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed":
+			fmt.Fprintf(w, `<rss><channel>
+				<item><guid>0</guid><title>0</title><link>%v/articles/0</link></item>
+				<item><guid>1</guid><title>1</title><link>%v/articles/1</link><author>Feed Author</author><category>feed</category></item>
+				</channel></rss>`, ts.URL, ts.URL)
+		default:
+			atomic.AddInt32(&pageRequests, 1)
+			fmt.Fprint(w, `<html><head><meta property="og:image" content="/og.jpg"><meta name="author" content="Page Author">
+				<meta property="article:tag" content="page"><meta property="og:locale" content="fr_FR"></head></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test-enrich", ContentURL: ts.URL + "/feed", Categories: []string{"c"}, EnrichMetadata: true}
+	for i := 0; i < 2; i++ {
+		content, _, _, err := ingestFromProvider(&TestConfig{}, p, &Index{}, &Report{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(content) != 2 {
+			t.Fatalf("Expected content of length 2, but got %v", len(content))
+		}
+
+		c := content[0]
+		if c.Image != ts.URL+"/og.jpg" || c.Author != "Page Author" || c.Language != "fr" || !reflect.DeepEqual(c.Tags, []string{"page", "c"}) {
+			t.Errorf("Expected content to be enriched, but got %v", c)
+		}
+		c = content[1]
+		if c.Author != "Feed Author" || !reflect.DeepEqual(c.Tags, []string{"feed", "c"}) {
+			t.Errorf("Expected metadata of feed to be kept, but got %v", c)
+		}
+	}
+
+	if pageRequests != 2 {
+		t.Errorf("Expected each page to be fetched once, but got %v requests", pageRequests)
+	}
+}
+
+func TestIngestFetchesArticlesOnceForProcessors(t *testing.T) {
+	var pageRequests int32
+	var userAgent atomic.Value
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed":
+			fmt.Fprintf(w, `<rss><channel>
+				<item><guid>0</guid><title>0</title><link>%v/articles/0</link><description>Teaser</description></item>
+				<item><guid>1</guid><title>1</title><link>%v/missing</link><description>Teaser</description></item>
+				</channel></rss>`, ts.URL, ts.URL)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			atomic.AddInt32(&pageRequests, 1)
+			userAgent.Store(r.UserAgent())
+			fmt.Fprint(w, `<html><head><meta name="author" content="Page Author"></head><body><div class="article">
+				<p>The moon will momentarily block Venus, then Mars and then Mercury, offering a vivid reminder.</p>
+				</div></body></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test-readability", ContentURL: ts.URL + "/feed", UserAgent: "crec", EnrichMetadata: true,
+		Processors: []string{"Readability"}}
+	err := p.createProcessors(processor.GetRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := &Report{}
+	content, _, _, err := ingestFromProvider(&TestConfig{}, p, &Index{}, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 2 {
+		t.Fatalf("Expected content of length 2, but got %v", len(content))
+	}
+
+	c := content[0]
+	if !strings.HasPrefix(c.Excerpt, "The moon") || c.Author != "Page Author" {
+		t.Errorf("Expected content to be extracted from the article and enriched, but got %v", c)
+	}
+	if pageRequests != 1 || userAgent.Load() != "crec" {
+		t.Errorf("Expected article to be fetched once using the provider's settings, but got %v requests", pageRequests)
+	}
+	if content[1].Excerpt != "Teaser" {
+		t.Errorf("Expected content of unavailable article to be kept, but got %v", content[1].Excerpt)
+	}
+	want := []PageFailure{{URL: ts.URL + "/missing", Reason: "Unexpected response status: 404 Not Found"}}
+	if !reflect.DeepEqual(report.FailedPages, want) {
+		t.Errorf("Expected unavailable article to be reported, but got %v", report.FailedPages)
+	}
+}
+
+func TestIngestUsesCanonicalURLsOfPages(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed":
+			fmt.Fprintf(w, `<rss><channel>
+				<item><title>0</title><link>%v/amp/0</link></item>
+				<item><title>0</title><link>%v/0?ref=home</link></item>
+				</channel></rss>`, ts.URL, ts.URL)
+		default:
+			fmt.Fprint(w, `<html><head><link rel="canonical" href="/0?utm_source=canonical"></head></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test-canonical", ContentURL: ts.URL + "/feed", EnrichMetadata: true}
+	content, _, _, err := ingestFromProvider(&TestConfig{}, p, &Index{}, &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 1 || content[0].ID != ts.URL+"/0" || content[0].URL != ts.URL+"/0" {
+		t.Errorf("Expected a single item with the canonical URL, but got %v", content)
+	}
+}
+
+func TestResolveCanonicalURL(t *testing.T) {
+	tests := []struct {
+		canonicals []string
+		want       string
+	}{
+		{[]string{"/news/story?ref=canonical"}, "https://www.example.com/news/story?ref=canonical"},
+		{[]string{"https://amp.example.com/news/story"}, "https://amp.example.com/news/story"},
+		{[]string{"https://www.example.com/", "/other"}, "https://www.example.com/other"},
+		{[]string{"https://www.example.com", "https://www.example.com/"}, ""},
+		{[]string{"https://www.other.com/news/story"}, ""},
+		{[]string{"https://www.example.co.uk/news/story", "/news/story?id=1"}, "https://www.example.com/news/story?id=1"},
+	}
+	for _, test := range tests {
+		if got := resolveCanonicalURL("https://www.example.com/news/story?ref=feed", test.canonicals...); got != test.want {
+			t.Errorf("Expected canonical URL %q for %v, but got %q", test.want, test.canonicals, got)
+		}
+	}
+}
+
+func TestRegistrableDomain(t *testing.T) {
+	tests := map[string]string{
+		"www.example.com":    "example.com",
+		"example.com":        "example.com",
+		"news.example.co.uk": "example.co.uk",
+		"www.example.de":     "example.de",
+		"localhost":          "localhost",
+	}
+	for host, want := range tests {
+		if got := registrableDomain(host); got != want {
+			t.Errorf("Expected %v for %v, but got %v", want, host, got)
+		}
+	}
+}
+
+func TestEnrichContentSkipsCompleteContent(t *testing.T) {
+	var pageRequests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&pageRequests, 1)
+		fmt.Fprint(w, `<html><head><link rel="canonical" href="/canonical"></head></html>`)
+	}))
+	defer ts.Close()
+
+	p := &Provider{ID: "test-complete", EnrichMetadata: true}
+	c := &Content{ID: ts.URL + "/complete", URL: ts.URL + "/complete", Image: ts.URL + "/image.jpg", Author: "Author",
+		Published: NewTimestamp(time.Now()), Language: "en", Tags: []string{"tag"}}
+	enrichContent(&TestConfig{}, p, &http.Client{}, newPoliteness(), []*Content{c})
+	if pageRequests != 0 || c.URL != ts.URL+"/complete" {
+		t.Errorf("Expected page of complete content not to be fetched, but got %v requests", pageRequests)
+	}
+
+	metadata.get(&TestConfig{}, p, &http.Client{}, newPoliteness(), c.URL)
+	enrichContent(&TestConfig{}, p, &http.Client{}, newPoliteness(), []*Content{c})
+	if pageRequests != 1 || c.URL != ts.URL+"/canonical" {
+		t.Errorf("Expected known canonical URL to be used, but got %v", c.URL)
+	}
+}
+
+func TestMetadataCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newMetadataCache(2)
+	cache.add("a", &pageMetadata{author: "a"})
+	cache.add("b", &pageMetadata{author: "b"})
+	cache.lookup("a")
+	cache.add("c", &pageMetadata{author: "c"})
+
+	if cache.lookup("b") != nil {
+		t.Error("Expected least recently used page to be evicted")
+	}
+	if m := cache.lookup("a"); m == nil || m.author != "a" {
+		t.Errorf("Expected recently used page to be retained, but got %v", m)
+	}
+	if m := cache.lookup("c"); m == nil || m.author != "c" {
+		t.Errorf("Expected added page to be cached, but got %v", m)
+	}
+}
+
+func TestFetchMetadataPreparesRequest(t *testing.T) {
+	var header http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		fmt.Fprint(w, `<html><head></head></html>`)
+	}))
+	defer ts.Close()
+
+	os.Setenv("TEST_METADATA_TOKEN", "secret")
+	defer os.Unsetenv("TEST_METADATA_TOKEN")
+	p := &Provider{ID: "test", ContentURL: ts.URL + "/feed", UserAgent: "crec/1.0",
+		Headers: map[string]string{"X-Test": "test"}, BearerTokenEnv: "TEST_METADATA_TOKEN"}
+	_, err := fetchMetadata(&TestConfig{}, p, &http.Client{}, newPoliteness(), ts.URL+"/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("User-Agent") != "crec/1.0" || header.Get("X-Test") != "test" || header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("Expected headers of the provider, but got %v", header)
+	}
+
+}
+
+func TestFetchMetadataFromOtherHost(t *testing.T) {
+	var header http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		fmt.Fprint(w, `<html><head></head></html>`)
+	}))
+	defer other.Close()
+
+	os.Setenv("TEST_METADATA_TOKEN", "secret")
+	defer os.Unsetenv("TEST_METADATA_TOKEN")
+	p := &Provider{ID: "test", ContentURL: "https://example.com/feed", UserAgent: "crec/1.0",
+		Headers: map[string]string{"X-Api-Key": "key"}, BasicAuthUserEnv: "TEST_METADATA_TOKEN", BearerTokenEnv: "TEST_METADATA_TOKEN"}
+	_, err := fetchMetadata(&TestConfig{}, p, &http.Client{}, newPoliteness(), other.URL+"/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("User-Agent") != "crec/1.0" {
+		t.Errorf("Expected user agent of the provider, but got %v", header.Get("User-Agent"))
+	}
+	if header.Get("X-Api-Key") != "" || header.Get("Authorization") != "" {
+		t.Errorf("Expected headers and credentials not to be sent to other hosts, but got %v", header)
+	}
+}
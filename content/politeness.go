@@ -0,0 +1,291 @@
+package content
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Time after which a host's robots.txt is fetched again
+const robotsTxtExpiry = 24 * time.Hour
+
+// Time after which a host's robots.txt is fetched again, if it couldn't be fetched
+const robotsTxtRetryInterval = 10 * time.Minute
+
+// Interval in which hosts no longer requested are removed from the host limiter
+// and robots.txt cache
+const idleHostExpiry = time.Minute
+
+// Maximum size of robots.txt files, the remainder is ignored
+const maxRobotsTxtSize = 500 * 1024
+
+// errDisallowedByRobots indicates that the host's robots.txt doesn't permit fetching content
+var errDisallowedByRobots = errors.New("Content disallowed by robots.txt")
+
+// politeness holds the state of all requests to content hosts, shared by all
+// providers and all views of an index (see Index.getPoliteness)
+type politeness struct {
+	hosts  *hostLimiter
+	robots *robotsCache
+}
+
+func newPoliteness() *politeness {
+	return &politeness{
+		hosts:  &hostLimiter{next: make(map[string]time.Time)},
+		robots: &robotsCache{hosts: make(map[string]*robotsTxt)}}
+}
+
+// hostLimiter spaces out requests to the same host
+type hostLimiter struct {
+	next   map[string]time.Time
+	pruned time.Time
+	mux    sync.Mutex
+}
+
+// wait blocks until the next request to the host is permitted, reserving the
+// following slot for the next caller
+func (l *hostLimiter) wait(host string, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	l.mux.Lock()
+	now := time.Now()
+	l.prune(now)
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(interval)
+	l.mux.Unlock()
+
+	time.Sleep(slot.Sub(now))
+}
+
+// prune removes hosts whose next slot has passed, at most once per idleHostExpiry
+func (l *hostLimiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < idleHostExpiry {
+		return
+	}
+	for host, slot := range l.next {
+		if slot.Before(now) {
+			delete(l.next, host)
+		}
+	}
+	l.pruned = now
+}
+
+// robotsTxt holds the rules of a robots.txt group
+type robotsTxt struct {
+	rules   []robotsRule
+	expires time.Time
+}
+
+type robotsRule struct {
+	path    string
+	pattern *regexp.Regexp
+	allow   bool
+}
+
+// robotsCache caches the robots.txt of hosts
+type robotsCache struct {
+	hosts  map[string]*robotsTxt
+	pruned time.Time
+	mux    sync.Mutex
+}
+
+// awaitHost waits for the provider's turn to request content from the host of its
+// ContentURL (see await)
+func (p *politeness) awaitHost(config Config, provider *Provider, client *http.Client) error {
+	u, err := url.Parse(provider.ContentURL)
+	if err != nil {
+		return err
+	}
+	return p.await(config, provider, client, u)
+}
+
+// await waits for the provider's turn to request the URL from its host. If enabled,
+// errDisallowedByRobots is returned if the host's robots.txt doesn't permit fetching
+// the URL.
+func (p *politeness) await(config Config, provider *Provider, client *http.Client, u *url.URL) error {
+	if config.RobotsTxtActive() {
+		rules := p.robots.get(config, provider, client, u, p.hosts)
+		if !rules.allowed(u.RequestURI()) {
+			return errDisallowedByRobots
+		}
+	}
+
+	p.hosts.wait(u.Host, config.GetHostRequestInterval())
+	return nil
+}
+
+// get returns the robots.txt rules applying to the provider, fetching them if
+// not cached or expired. If robots.txt is unreachable (see RFC 9309, section
+// 2.3.1.3), the previously fetched rules are kept, or all content is disallowed
+// if there are none, until robots.txt is fetched again shortly after.
+func (c *robotsCache) get(config Config, provider *Provider, client *http.Client, u *url.URL, hosts *hostLimiter) *robotsTxt {
+	key := u.Scheme + "://" + u.Host + "|" + provider.UserAgent
+	c.mux.Lock()
+	cached, ok := c.hosts[key]
+	c.mux.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached
+	}
+
+	hosts.wait(u.Host, config.GetHostRequestInterval())
+	rules, err := fetchRobotsTxt(provider, client, u)
+	if err == nil {
+		rules.expires = time.Now().Add(robotsTxtExpiry)
+	} else {
+		log.Printf("Failed to fetch robots.txt for provider %v: %v", provider.ID, err)
+		if ok {
+			rules = &robotsTxt{rules: cached.rules}
+		} else {
+			rules = &robotsTxt{rules: []robotsRule{{path: "/", pattern: compileRobotsPath("/")}}}
+		}
+		rules.expires = time.Now().Add(robotsTxtRetryInterval)
+	}
+
+	c.mux.Lock()
+	c.prune(time.Now())
+	c.hosts[key] = rules
+	c.mux.Unlock()
+	return rules
+}
+
+// prune removes the robots.txt of hosts which weren't requested for robotsTxtExpiry
+// after it expired, at most once per idleHostExpiry
+func (c *robotsCache) prune(now time.Time) {
+	if now.Sub(c.pruned) < idleHostExpiry {
+		return
+	}
+	for key, rules := range c.hosts {
+		if now.Sub(rules.expires) > robotsTxtExpiry {
+			delete(c.hosts, key)
+		}
+	}
+	c.pruned = now
+}
+
+func fetchRobotsTxt(provider *Provider, client *http.Client, u *url.URL) (*robotsTxt, error) {
+	req, err := http.NewRequest("GET", u.Scheme+"://"+u.Host+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	if provider.UserAgent != "" {
+		req.Header.Set("User-Agent", provider.UserAgent)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Missing robots.txt files permit everything, unreachable ones are failures
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return &robotsTxt{}, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+	return parseRobotsTxt(io.LimitReader(resp.Body, maxRobotsTxtSize), userAgentToken(req.UserAgent())), nil
+}
+
+// userAgentToken returns the product token of a user agent e.g. crec for crec/1.0
+func userAgentToken(userAgent string) string {
+	fields := strings.Fields(userAgent)
+	if len(fields) == 0 {
+		return "Go-http-client"
+	}
+	return strings.SplitN(fields[0], "/", 2)[0]
+}
+
+// parseRobotsTxt returns the rules of the group matching the user agent, or of
+// the default group (*) if no group matches
+func parseRobotsTxt(r io.Reader, userAgent string) *robotsTxt {
+	var matching, fallback []robotsRule
+	var agents []string
+	inRules := false
+	foundMatching := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		value := strings.TrimSpace(kv[1])
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share the following rules
+			if inRules {
+				agents = nil
+				inRules = false
+			}
+			agents = append(agents, strings.ToLower(value))
+			if strings.EqualFold(value, userAgent) {
+				foundMatching = true
+			}
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{path: value, pattern: compileRobotsPath(value), allow: key == "allow"}
+			for _, agent := range agents {
+				if agent == "*" {
+					fallback = append(fallback, rule)
+				} else if agent == strings.ToLower(userAgent) {
+					matching = append(matching, rule)
+				}
+			}
+		}
+	}
+
+	if foundMatching {
+		return &robotsTxt{rules: matching}
+	}
+	return &robotsTxt{rules: fallback}
+}
+
+// allowed returns true if the path is permitted by the most specific (longest)
+// matching rule. Allow rules take precedence over equally specific disallow rules.
+func (r *robotsTxt) allowed(path string) bool {
+	allowed := true
+	longest := -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if len(rule.path) > longest || (len(rule.path) == longest && rule.allow) {
+			allowed = rule.allow
+			longest = len(rule.path)
+		}
+	}
+	return allowed
+}
+
+// compileRobotsPath converts a rule's path, which may contain wildcards (*) and be
+// anchored at the end ($), into a regular expression matching path prefixes
+func compileRobotsPath(path string) *regexp.Regexp {
+	anchored := strings.HasSuffix(path, "$")
+	expr := "^" + strings.Replace(regexp.QuoteMeta(strings.TrimSuffix(path, "$")), `\*`, ".*", -1)
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
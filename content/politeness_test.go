@@ -0,0 +1,216 @@
+package content
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type politeConfig struct {
+	TestConfig
+}
+
+func (c *politeConfig) GetHostRequestInterval() time.Duration {
+	return 50 * time.Millisecond
+}
+func (c *politeConfig) RobotsTxtActive() bool {
+	return true
+}
+
+func TestParseRobotsTxt(t *testing.T) {
+	robotsTxt := `
+# Comments are ignored
+User-agent: *
+Disallow: /private/
+Allow: /private/feed.xml
+
+User-agent: crec
+User-agent: other
+Disallow: /*.json$
+Allow: /feeds/*.json$
+Disallow: /internal
+
+User-agent: blocked
+Disallow: /`
+
+	tests := []struct {
+		userAgent string
+		path      string
+		want      bool
+	}{
+		{"Go-http-client", "/feed.xml", true},
+		{"Go-http-client", "/private/feed", false},
+		{"Go-http-client", "/private/feed.xml", true},
+		{"crec", "/private/feed", true},
+		{"crec", "/content.json", false},
+		{"crec", "/content.json?page=2", true},
+		{"crec", "/feeds/content.json", true},
+		{"crec", "/internal/feed", false},
+		{"Blocked", "/feed.xml", false},
+	}
+
+	for _, test := range tests {
+		got := parseRobotsTxt(strings.NewReader(robotsTxt), test.userAgent).allowed(test.path)
+		if got != test.want {
+			t.Errorf("Expected %v for %v requesting %v, but got %v", test.want, test.userAgent, test.path, got)
+		}
+	}
+}
+
+func TestHostLimiterSpacesOutRequests(t *testing.T) {
+	limiter := &hostLimiter{next: make(map[string]time.Time)}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.wait("example.com", 20*time.Millisecond)
+	}
+	limiter.wait("other.example.com", 20*time.Millisecond)
+
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected requests to the same host to be spaced out, but took %v", elapsed)
+	}
+
+	// Idle hosts are removed
+	time.Sleep(60 * time.Millisecond)
+	limiter.pruned = time.Time{}
+	limiter.wait("other.example.com", 20*time.Millisecond)
+	if _, ok := limiter.next["example.com"]; ok || len(limiter.next) != 1 {
+		t.Errorf("Expected idle host to be removed, but got %v", limiter.next)
+	}
+}
+
+func TestIngestFromProviderRespectsRobotsTxt(t *testing.T) {
+	var mux sync.Mutex
+	requests := make([]time.Time, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprintln(w, "User-agent: crec\nDisallow: /private")
+		default:
+			mux.Lock()
+			requests = append(requests, time.Now())
+			mux.Unlock()
+			fmt.Fprintln(w, `[{"id":"0"}]`)
+		}
+	}))
+	defer ts.Close()
+
+	config := &politeConfig{}
+	index := &Index{}
+	_, _, _, err := ingestFromProvider(config, &Provider{ID: "test", ContentURL: ts.URL + "/private/feed", Native: true, UserAgent: "crec/1.0"}, index, &Report{})
+	if err != errDisallowedByRobots {
+		t.Errorf("Expected content to be disallowed, but got %v", err)
+	}
+
+	provider := &Provider{ID: "test", ContentURL: ts.URL + "/public/feed", Native: true, UserAgent: "crec/1.0"}
+	for i := 0; i < 2; i++ {
+		content, _, _, err := ingestFromProvider(config, provider, createView(config, index), &Report{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(content) != 1 {
+			t.Errorf("Expected content of length 1, but got %v", len(content))
+		}
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 content requests, but got %v", len(requests))
+	}
+	if requests[1].Sub(requests[0]) < config.GetHostRequestInterval()/2 {
+		t.Errorf("Expected requests to be spaced out, but got %v", requests[1].Sub(requests[0]))
+	}
+}
+
+func TestRobotsCacheHandlesUnreachableRobotsTxt(t *testing.T) {
+	status := http.StatusInternalServerError
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprintln(w, "User-agent: *\nDisallow: /private")
+	}))
+	defer ts.Close()
+
+	config := &TestConfig{}
+	provider := &Provider{ID: "test"}
+	u, _ := url.Parse(ts.URL + "/public/feed")
+	cache := &robotsCache{hosts: make(map[string]*robotsTxt)}
+
+	rules := cache.get(config, provider, &http.Client{}, u, &hostLimiter{next: make(map[string]time.Time)})
+	if rules.allowed(u.RequestURI()) {
+		t.Error("Expected content to be disallowed if robots.txt is unreachable")
+	}
+	if rules.expires.After(time.Now().Add(robotsTxtRetryInterval)) {
+		t.Errorf("Expected failure to be cached briefly, but expires %v", rules.expires)
+	}
+
+	// Expired rules are kept if robots.txt is unreachable
+	status = http.StatusOK
+	for _, cached := range cache.hosts {
+		cached.expires = time.Time{}
+	}
+	rules = cache.get(config, provider, &http.Client{}, u, &hostLimiter{next: make(map[string]time.Time)})
+	if !rules.allowed(u.RequestURI()) || rules.allowed("/private") {
+		t.Error("Expected fetched rules to apply")
+	}
+
+	status = http.StatusServiceUnavailable
+	for _, cached := range cache.hosts {
+		cached.expires = time.Time{}
+	}
+	rules = cache.get(config, provider, &http.Client{}, u, &hostLimiter{next: make(map[string]time.Time)})
+	if !rules.allowed(u.RequestURI()) || rules.allowed("/private") {
+		t.Error("Expected previously fetched rules to be kept")
+	}
+
+	// The robots.txt of idle hosts is removed
+	for _, cached := range cache.hosts {
+		cached.expires = time.Now().Add(-2 * robotsTxtExpiry)
+	}
+	cache.pruned = time.Time{}
+	cache.prune(time.Now())
+	if len(cache.hosts) != 0 {
+		t.Errorf("Expected robots.txt of idle host to be removed, but got %v", cache.hosts)
+	}
+}
+
+func TestIngestLimitsConcurrency(t *testing.T) {
+	var mux sync.Mutex
+	active, maxActive := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mux.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprintln(w, `[{"id":"`+r.URL.Path[1:]+`"}]`)
+
+		mux.Lock()
+		active--
+		mux.Unlock()
+	}))
+	defer ts.Close()
+
+	config := &TestConfig{}
+	providers := make(Providers)
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("test-concurrency-%v", i)
+		providers[id] = &Provider{ID: id, ContentURL: ts.URL + "/" + id, Native: true}
+		defer os.RemoveAll(filepath.Join(config.GetImportQueueDir(), id))
+	}
+
+	index := Ingest(config, providers, &Index{})
+	if len(index.GetContent()) != 5 {
+		t.Errorf("Expected content of all providers, but got %v", len(index.GetContent()))
+	}
+	if maxActive > config.GetProviderConcurrency() {
+		t.Errorf("Expected at most %v concurrent requests, but got %v", config.GetProviderConcurrency(), maxActive)
+	}
+}
@@ -1,7 +1,8 @@
 package processor
 
 import (
-	"github.com/andybalholm/cascadia"
+	"regexp"
+
 	"golang.org/x/net/html"
 )
 
@@ -13,7 +14,8 @@ func removeNodes(context *Context, nodeNames []string) (*Context, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Context{Content: node, HTML: true, Result: context.Result}, nil
+	context.Content = node
+	return context, nil
 }
 
 func removeHTMLNodes(node *html.Node, nodeNames []string) (*html.Node, error) {
@@ -48,20 +50,12 @@ func findNodesToRemove(n *html.Node, nodeNames []string) []*html.Node {
 	return nodes
 }
 
-func findAttributeValueOfFirstMatch(context *Context, elem string, attr string) (string, error) {
-	if !context.HTML {
-		return "", nil
+func replaceText(n *html.Node, pattern *regexp.Regexp, replacement string, replaced map[*html.Node]bool) {
+	if n.Type == html.TextNode && !replaced[n] {
+		n.Data = pattern.ReplaceAllString(n.Data, replacement)
+		replaced[n] = true
 	}
-	node := context.Content.(*html.Node)
-	img := cascadia.MustCompile(elem).MatchFirst(node)
-	if img != nil {
-		for _, a := range img.Attr {
-			if a.Key == attr {
-				if a.Val != "" {
-					return a.Val, nil
-				}
-			}
-		}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		replaceText(c, pattern, replacement, replaced)
 	}
-	return "", nil
 }
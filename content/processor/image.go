@@ -0,0 +1,136 @@
+package processor
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Attributes of img elements holding the image source, in order of preference.
+// Lazy-loading scripts keep the actual source in data attributes.
+var imageSourceAttributes = []string{"srcset", "data-srcset", "data-src", "data-lazy-src", "data-original", "src"}
+
+// Matches hosts and paths of tracking pixels and spacers
+var trackingImage = regexp.MustCompile(`(?i)^(feeds\.feedburner\.com/~(r|ff)/|stats\.wordpress\.com/|pixel\.wp\.com/|[^/]*doubleclick\.net/)|/(pixel|beacon|spacer|blank|clear|transparent|1x1)(\.gif|\.png)?$`)
+
+// ResolveImageURL returns the absolute URL of the image, resolved against the base
+// URL of the item, if any. Protocol-relative URLs use HTTPS. An empty string is
+// returned for invalid URLs, data URIs and tracking pixels.
+func ResolveImageURL(base string, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "data:") {
+		return ""
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if b, err := url.Parse(base); err == nil && b.IsAbs() {
+		u = b.ResolveReference(u)
+	}
+	if u.Scheme == "" && u.Host != "" {
+		u.Scheme = "https"
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+
+	if trackingImage.MatchString(u.Host + u.Path) {
+		return ""
+	}
+	return u.String()
+}
+
+// PreferHTTPS returns the first of the images served over HTTPS, falling back to
+// the first image. Empty candidates are ignored.
+func PreferHTTPS(images ...string) string {
+	first := ""
+	for _, image := range images {
+		if image == "" {
+			continue
+		}
+		if strings.HasPrefix(image, "https:") {
+			return image
+		}
+		if first == "" {
+			first = image
+		}
+	}
+	return first
+}
+
+// findImage returns the resolved URL of the first image within the node which
+// isn't a tracking pixel
+func findImage(node *html.Node, base string) string {
+	if node.Type == html.ElementNode && node.Data == "img" && !isPixel(node) {
+		if image := imageSource(node, base); image != "" {
+			return image
+		}
+	}
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		if image := findImage(c, base); image != "" {
+			return image
+		}
+	}
+	return ""
+}
+
+// imageSource returns the resolved source of the img element, preferring the
+// largest candidate of a srcset
+func imageSource(img *html.Node, base string) string {
+	for _, key := range imageSourceAttributes {
+		for _, a := range img.Attr {
+			if a.Key != key {
+				continue
+			}
+			val := a.Val
+			if strings.HasSuffix(key, "srcset") {
+				val = largestSrcsetCandidate(val)
+			}
+			if image := ResolveImageURL(base, val); image != "" {
+				return image
+			}
+		}
+	}
+	return ""
+}
+
+// largestSrcsetCandidate returns the URL of the srcset candidate with the largest
+// width or pixel density descriptor e.g. "a.jpg 320w, b.jpg 640w" returns b.jpg
+func largestSrcsetCandidate(srcset string) string {
+	largest := ""
+	largestSize := -1.0
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		size := 1.0
+		if len(fields) > 1 {
+			descriptor := fields[1]
+			if f, err := strconv.ParseFloat(descriptor[:len(descriptor)-1], 64); err == nil {
+				size = f
+			}
+		}
+		if size > largestSize {
+			largest, largestSize = fields[0], size
+		}
+	}
+	return largest
+}
+
+// isPixel returns true if the img element declares a width or height of at most 1
+func isPixel(img *html.Node) bool {
+	for _, a := range img.Attr {
+		if a.Key == "width" || a.Key == "height" {
+			if size, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(a.Val), "px")); err == nil && size <= 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
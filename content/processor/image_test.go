@@ -0,0 +1,51 @@
+package processor
+
+import "testing"
+
+func TestImageExtractorDiscoversImages(t *testing.T) {
+	tests := []struct {
+		html string
+		url  string
+		want string
+	}{
+		{`<img src="https://example.com/a.png">`, "", "https://example.com/a.png"},
+		{`<img src="/a.png">`, "https://example.com/articles/1", "https://example.com/a.png"},
+		{`<img src="a.png">`, "https://example.com/articles/1", "https://example.com/articles/a.png"},
+		{`<img src="//cdn.example.com/a.png">`, "", "https://cdn.example.com/a.png"},
+		{`<img src="data:image/gif;base64,R0lGOD" data-src="/lazy.png">`, "http://example.com", "http://example.com/lazy.png"},
+		{`<img src="a.png" srcset="a-320.png 320w, a-1024.png 1024w, a-640.png 640w">`, "https://example.com/", "https://example.com/a-1024.png"},
+		{`<img data-srcset="a.png 1x, a@2x.png 2x">`, "https://example.com/", "https://example.com/a@2x.png"},
+		{`<img src="https://example.com/t.gif" width="1" height="1"><img src="https://example.com/b.png">`, "", "https://example.com/b.png"},
+		{`<img src="https://feeds.feedburner.com/~r/example/~4/abc"><img src="https://example.com/spacer.gif"><img src="https://example.com/c.png">`, "", "https://example.com/c.png"},
+		{`<img src="/relative.png">`, "", ""},
+		{`<p>No image</p>`, "https://example.com", ""},
+	}
+
+	for _, test := range tests {
+		ctx, err := getContext(test.html)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx.URL = test.url
+
+		ctx, err = ImageExtractor{}.Process(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ctx.Result["image"]; got != test.want {
+			t.Errorf("Expected image %q for %v, but got %q", test.want, test.html, got)
+		}
+	}
+}
+
+func TestPreferHTTPS(t *testing.T) {
+	if got := PreferHTTPS("", "http://example.com/a.png", "https://example.com/b.png"); got != "https://example.com/b.png" {
+		t.Errorf("Expected HTTPS image, but got %v", got)
+	}
+	if got := PreferHTTPS("", "http://example.com/a.png", "http://example.com/b.png"); got != "http://example.com/a.png" {
+		t.Errorf("Expected first image, but got %v", got)
+	}
+	if got := PreferHTTPS(""); got != "" {
+		t.Errorf("Expected no image, but got %v", got)
+	}
+}
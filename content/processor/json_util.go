@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NewJSONContext creates a new JSON specific processing context, holding a value
+// decoded using encoding/json e.g. map[string]interface{}
+func NewJSONContext(content interface{}) *Context {
+	return &Context{Content: content, JSON: true, Result: make(map[string]string)}
+}
+
+// Lookup returns the values found at the path within the decoded JSON value. Paths
+// consist of object keys and array indices separated by dots e.g. data.items or
+// media.0.url. A * matches all elements of an array, or all values of an object
+// ordered by key, e.g. tags.*.name. An empty path returns the value itself.
+func Lookup(value interface{}, path string) []interface{} {
+	values := []interface{}{value}
+	if path == "" {
+		return values
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		next := make([]interface{}, 0)
+		for _, v := range values {
+			next = append(next, lookupSegment(v, segment)...)
+		}
+		values = next
+	}
+	return values
+}
+
+func lookupSegment(value interface{}, segment string) []interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if segment == "*" {
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			values := make([]interface{}, 0, len(v))
+			for _, key := range keys {
+				values = append(values, v[key])
+			}
+			return values
+		}
+		if val, ok := v[segment]; ok && val != nil {
+			return []interface{}{val}
+		}
+	case []interface{}:
+		if segment == "*" {
+			return v
+		}
+		i, err := strconv.Atoi(segment)
+		if err == nil && i >= 0 && i < len(v) && v[i] != nil {
+			return []interface{}{v[i]}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/andybalholm/cascadia"
+)
+
+// Params holds the parameters of a processor, as declared in the provider registry
+type Params map[string]interface{}
+
+// Configurable is implemented by processors accepting parameters
+type Configurable interface {
+	Configure(params Params) error
+}
+
+// String returns the string parameter with the given name, or an empty string if missing
+func (p Params) String(name string) (string, error) {
+	val, ok := p[name]
+	if !ok {
+		return "", nil
+	}
+	s, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("Parameter %v must be a string", name)
+	}
+	return s, nil
+}
+
+// Int returns the integer parameter with the given name, or def if missing
+func (p Params) Int(name string, def int) (int, error) {
+	val, ok := p[name]
+	if !ok {
+		return def, nil
+	}
+	switch val := val.(type) {
+	case int:
+		return val, nil
+	case int64:
+		return int(val), nil
+	}
+	return 0, fmt.Errorf("Parameter %v must be an integer", name)
+}
+
+// Strings returns the list of strings with the given name, or nil if missing
+func (p Params) Strings(name string) ([]string, error) {
+	val, ok := p[name]
+	if !ok {
+		return nil, nil
+	}
+	switch val := val.(type) {
+	case []string:
+		return val, nil
+	case []interface{}:
+		strings := make([]string, 0)
+		for _, v := range val {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("Parameter %v must be a list of strings", name)
+			}
+			strings = append(strings, s)
+		}
+		return strings, nil
+	}
+	return nil, fmt.Errorf("Parameter %v must be a list of strings", name)
+}
+
+// Selector returns the compiled CSS selector with the given name, or nil if missing
+func (p Params) Selector(name string) (cascadia.Selector, error) {
+	s, err := p.String(name)
+	if err != nil || s == "" {
+		return nil, err
+	}
+	selector, err := cascadia.Compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("Parameter %v is not a valid selector: %v", name, err)
+	}
+	return selector, nil
+}
+
+// Regexp returns the compiled regular expression with the given name, or nil if missing
+func (p Params) Regexp(name string) (*regexp.Regexp, error) {
+	s, err := p.String(name)
+	if err != nil || s == "" {
+		return nil, err
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("Parameter %v is not a valid regular expression: %v", name, err)
+	}
+	return re, nil
+}
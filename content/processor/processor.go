@@ -1,10 +1,14 @@
 package processor
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
-	"strings"
+	"regexp"
+	"sync"
 
-	"log"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
 )
 
 // Registry manages a global mapping of process names to types
@@ -12,24 +16,86 @@ type Registry struct {
 	processors map[string]reflect.Type
 }
 
-// GetRegistry create a new processor registry
+// Processors registered in addition to the built-in ones, see Register
+var registered = &Registry{processors: make(map[string]reflect.Type)}
+var registeredMux sync.Mutex
+
+// GetRegistry create a new processor registry, holding the built-in processors
+// and all processors registered using Register
 func GetRegistry() *Registry {
+	r := getBuiltInRegistry()
+	registeredMux.Lock()
+	defer registeredMux.Unlock()
+	for name, processor := range registered.processors {
+		r.processors[name] = processor
+	}
+	return r
+}
+
+func getBuiltInRegistry() *Registry {
 	r := &Registry{}
 	r.processors = make(map[string]reflect.Type)
 	r.processors["ExternalLinkRemover"] = reflect.TypeOf(ExternalLinkRemover{})
 	r.processors["ImageExtractor"] = reflect.TypeOf(ImageExtractor{})
 	r.processors["BoldElementRemover"] = reflect.TypeOf(BoldElementRemover{})
+	r.processors["ElementRemover"] = reflect.TypeOf(ElementRemover{})
+	r.processors["AttributeRewriter"] = reflect.TypeOf(AttributeRewriter{})
+	r.processors["TextReplacer"] = reflect.TypeOf(TextReplacer{})
+	r.processors["Readability"] = reflect.TypeOf(Readability{})
+	r.processors["JSONFilter"] = reflect.TypeOf(JSONFilter{})
 	return r
 }
 
-// GetNewProcessor returns the content processor with the given name
-func (r *Registry) GetNewProcessor(name string) Processor {
+// Register makes the processor available to all registries created afterwards,
+// so providers can refer to it by name. A new instance of the processor's type
+// is created for every provider, and configured if it implements Configurable.
+func Register(name string, processor Processor) error {
+	registeredMux.Lock()
+	defer registeredMux.Unlock()
+	if _, ok := getBuiltInRegistry().processors[name]; ok {
+		return errors.New("Content processor " + name + " is already registered")
+	}
+	return registered.Register(name, processor)
+}
+
+// Register adds the processor to this registry
+func (r *Registry) Register(name string, processor Processor) error {
+	if name == "" {
+		return errors.New("Content processor name must not be empty")
+	}
+	if processor == nil {
+		return errors.New("Content processor " + name + " must not be nil")
+	}
+	if _, ok := r.processors[name]; ok {
+		return errors.New("Content processor " + name + " is already registered")
+	}
+
+	t := reflect.TypeOf(processor)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	r.processors[name] = t
+	return nil
+}
+
+// GetNewProcessor returns the content processor with the given name, configured
+// using the provided parameters, if it accepts any
+func (r *Registry) GetNewProcessor(name string, params Params) (Processor, error) {
 	processor, ok := r.processors[name]
 	if !ok {
-		log.Fatal("Couldn't find content processor with name " + name)
+		return nil, errors.New("Couldn't find content processor with name " + name)
 	}
 
-	return reflect.New(processor).Elem().Interface().(Processor)
+	p := reflect.New(processor)
+	if configurable, ok := p.Interface().(Configurable); ok {
+		err := configurable.Configure(params)
+		if err != nil {
+			return nil, err
+		}
+	} else if v, ok := p.Elem().Interface().(Processor); ok {
+		return v, nil
+	}
+	return p.Interface().(Processor), nil
 }
 
 // Context provides a processing context passed between processors
@@ -38,6 +104,14 @@ type Context struct {
 	HTML    bool
 	JSON    bool
 	Result  map[string]string
+
+	// URL of the processed item e.g. the link of a feed item, if known
+	URL string
+
+	// Fetch returns the body of a page linked by the item e.g. its article, if
+	// pages can be fetched. The ingester fetches pages politely, using the
+	// provider's settings, and records failures in its report.
+	Fetch func(pageURL string) ([]byte, error)
 }
 
 // NewHTMLContext creates a new HTML specific processing context
@@ -66,18 +140,190 @@ func (p BoldElementRemover) Process(context *Context) (*Context, error) {
 	return removeNodes(context, []string{"b"})
 }
 
-// ImageExtractor processes content to find an image URI
+// ImageExtractor processes content to find an image URI. Image sources are
+// taken from srcset and the attributes of lazy-loading scripts, relative URLs are
+// resolved against the URL of the item, and tracking pixels are skipped.
 type ImageExtractor struct{}
 
 // Process the provided content
 func (p ImageExtractor) Process(context *Context) (*Context, error) {
-	val, err := findAttributeValueOfFirstMatch(context, "img", "src")
+	if !context.HTML {
+		return context, nil
+	}
+	image := findImage(context.Content.(*html.Node), context.URL)
+	if image != "" {
+		context.Result["image"] = image
+	}
+	return context, nil
+}
+
+// ElementRemover removes all elements matching any of the CSS selectors specified
+// in the Selectors parameter
+type ElementRemover struct {
+	selectors []cascadia.Selector
+}
+
+// Configure the selectors of elements to remove
+func (p *ElementRemover) Configure(params Params) error {
+	selectors, err := params.Strings("Selectors")
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if len(selectors) == 0 {
+		return errors.New("Missing parameter Selectors")
+	}
+	for _, s := range selectors {
+		selector, err := cascadia.Compile(s)
+		if err != nil {
+			return errors.New("Invalid selector " + s + ": " + err.Error())
+		}
+		p.selectors = append(p.selectors, selector)
+	}
+	return nil
+}
+
+// Process the provided content
+func (p *ElementRemover) Process(context *Context) (*Context, error) {
+	if !context.HTML {
+		return context, nil
+	}
+	node := context.Content.(*html.Node)
+	for _, selector := range p.selectors {
+		for _, n := range selector.MatchAll(node) {
+			if n.Parent != nil {
+				n.Parent.RemoveChild(n)
+			}
+		}
+	}
+	return context, nil
+}
+
+// AttributeRewriter replaces matches of the regular expression Pattern in the
+// values of the Attribute of all elements matching Selector. Replacement can
+// refer to submatches e.g. $1. If no Pattern is specified, the entire value is
+// replaced.
+type AttributeRewriter struct {
+	selector    cascadia.Selector
+	attribute   string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Configure the attributes to rewrite
+func (p *AttributeRewriter) Configure(params Params) error {
+	var err error
+	if p.selector, err = params.Selector("Selector"); err != nil {
+		return err
+	}
+	if p.attribute, err = params.String("Attribute"); err != nil {
+		return err
+	}
+	if p.pattern, err = params.Regexp("Pattern"); err != nil {
+		return err
+	}
+	if p.replacement, err = params.String("Replacement"); err != nil {
+		return err
+	}
+	if p.selector == nil || p.attribute == "" {
+		return errors.New("Missing parameter Selector or Attribute")
+	}
+	if p.pattern == nil {
+		p.pattern = regexp.MustCompile("^.*$")
+	}
+	return nil
+}
+
+// Process the provided content
+func (p *AttributeRewriter) Process(context *Context) (*Context, error) {
+	if !context.HTML {
+		return context, nil
+	}
+	for _, n := range p.selector.MatchAll(context.Content.(*html.Node)) {
+		for i, a := range n.Attr {
+			if a.Key == p.attribute {
+				n.Attr[i].Val = p.pattern.ReplaceAllString(a.Val, p.replacement)
+			}
+		}
+	}
+	return context, nil
+}
+
+// TextReplacer replaces matches of the regular expression Pattern in text with
+// Replacement, optionally limited to the text of elements matching Selector
+type TextReplacer struct {
+	selector    cascadia.Selector
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Configure the text to replace
+func (p *TextReplacer) Configure(params Params) error {
+	var err error
+	if p.selector, err = params.Selector("Selector"); err != nil {
+		return err
+	}
+	if p.pattern, err = params.Regexp("Pattern"); err != nil {
+		return err
+	}
+	if p.replacement, err = params.String("Replacement"); err != nil {
+		return err
+	}
+	if p.pattern == nil {
+		return errors.New("Missing parameter Pattern")
+	}
+	return nil
+}
+
+// Process the provided content
+func (p *TextReplacer) Process(context *Context) (*Context, error) {
+	if !context.HTML {
+		return context, nil
+	}
+	node := context.Content.(*html.Node)
+	roots := []*html.Node{node}
+	if p.selector != nil {
+		roots = p.selector.MatchAll(node)
+	}
+
+	replaced := make(map[*html.Node]bool)
+	for _, root := range roots {
+		replaceText(root, p.pattern, p.replacement, replaced)
+	}
+	return context, nil
+}
+
+// JSONFilter skips JSON items with a value at Path (see Lookup) matching the
+// regular expression Pattern e.g. sponsored items. Values other than strings are
+// matched in their textual form e.g. true or 42.
+type JSONFilter struct {
+	path    string
+	pattern *regexp.Regexp
+}
+
+// Configure the path and pattern of values to skip
+func (p *JSONFilter) Configure(params Params) error {
+	var err error
+	if p.path, err = params.String("Path"); err != nil {
+		return err
+	}
+	if p.pattern, err = params.Regexp("Pattern"); err != nil {
+		return err
+	}
+	if p.path == "" || p.pattern == nil {
+		return errors.New("Missing parameter Path or Pattern")
+	}
+	return nil
+}
+
+// Process the provided content, returning an error if the item is to be skipped
+func (p *JSONFilter) Process(context *Context) (*Context, error) {
+	if !context.JSON {
+		return context, nil
 	}
-	if !strings.HasPrefix(val, "http:") {
-		val = "http:" + val
+	for _, v := range Lookup(context.Content, p.path) {
+		if value := fmt.Sprint(v); p.pattern.MatchString(value) {
+			return context, fmt.Errorf("Skipped item with %v %v", p.path, value)
+		}
 	}
-	context.Result["image"] = val
 	return context, nil
 }
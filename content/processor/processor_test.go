@@ -1,6 +1,8 @@
 package processor
 
 import (
+	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -71,7 +73,13 @@ func TestImageExtractor(t *testing.T) {
 func TestGetRegistry(t *testing.T) {
 	reg := GetRegistry()
 	for k, v := range reg.processors {
-		processor := reg.GetNewProcessor(k)
+		if _, ok := reflect.New(v).Interface().(Configurable); ok {
+			continue
+		}
+		processor, err := reg.GetNewProcessor(k, nil)
+		if err != nil {
+			t.Error(err)
+		}
 		if processor == nil {
 			t.Errorf("Failed to instantiate content processor with name: %v", processor)
 		}
@@ -82,6 +90,237 @@ func TestGetRegistry(t *testing.T) {
 	}
 }
 
+func TestElementRemover(t *testing.T) {
+	ctx, err := getContext("<html><body><div class=\"ad\"><p>ad</p></div><aside><div class=\"ad\"></div></aside><p>text</p></body></html>")
+	if err != nil {
+		t.Error(err)
+	}
+
+	p, err := GetRegistry().GetNewProcessor("ElementRemover", Params{"Selectors": []interface{}{"div.ad", "aside"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err = p.Process(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	got := render(t, ctx)
+	if got != "<html><head></head><body><p>text</p></body></html>" {
+		t.Errorf("Expected matching elements to be removed, but got %v", got)
+	}
+}
+
+func TestAttributeRewriter(t *testing.T) {
+	ctx, err := getContext("<html><body><img src=\"http://example.com/a.png?utm_source=feed\"/><a href=\"http://example.com\">a</a></body></html>")
+	if err != nil {
+		t.Error(err)
+	}
+
+	p, err := GetRegistry().GetNewProcessor("AttributeRewriter",
+		Params{"Selector": "img", "Attribute": "src", "Pattern": "^http://([^?]*).*$", "Replacement": "https://$1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err = p.Process(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	got := render(t, ctx)
+	if got != "<html><head></head><body><img src=\"https://example.com/a.png\"/><a href=\"http://example.com\">a</a></body></html>" {
+		t.Errorf("Expected image source to be rewritten, but got %v", got)
+	}
+}
+
+func TestTextReplacer(t *testing.T) {
+	ctx, err := getContext("<html><body><p>Read more at example.com</p><div><p>Read more</p></div></body></html>")
+	if err != nil {
+		t.Error(err)
+	}
+
+	p, err := GetRegistry().GetNewProcessor("TextReplacer", Params{"Selector": "div", "Pattern": "Read (more)", "Replacement": "$1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err = p.Process(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	got := render(t, ctx)
+	if got != "<html><head></head><body><p>Read more at example.com</p><div><p>more</p></div></body></html>" {
+		t.Errorf("Expected text to be replaced, but got %v", got)
+	}
+}
+
+func TestJSONFilter(t *testing.T) {
+	p, err := GetRegistry().GetNewProcessor("JSONFilter", Params{"Path": "labels.*", "Pattern": "^(sponsored|true)$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		item string
+		want bool
+	}{
+		{`{"labels": {"type": "sponsored"}}`, false},
+		{`{"labels": {"promoted": true}}`, false},
+		{`{"labels": {"type": "news", "promoted": false}}`, true},
+		{`{"title": "unlabeled"}`, true},
+	}
+	for _, test := range tests {
+		var item interface{}
+		json.Unmarshal([]byte(test.item), &item)
+		_, err := p.Process(NewJSONContext(item))
+		if (err == nil) != test.want {
+			t.Errorf("Expected %v to be kept: %v, but got %v", test.item, test.want, err)
+		}
+	}
+
+	ctx, err := getContext("<p>sponsored</p>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Process(ctx); err != nil {
+		t.Errorf("Expected HTML content to be ignored, but got %v", err)
+	}
+}
+
+func TestGetNewProcessorWithInvalidParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+	}{
+		{"ElementRemover", nil},
+		{"ElementRemover", Params{"Selectors": "div"}},
+		{"ElementRemover", Params{"Selectors": []interface{}{"div["}}},
+		{"AttributeRewriter", Params{"Selector": "img"}},
+		{"AttributeRewriter", Params{"Selector": "img", "Attribute": "src", "Pattern": "("}},
+		{"TextReplacer", Params{"Replacement": "text"}},
+		{"JSONFilter", Params{"Path": "type"}},
+		{"JSONFilter", Params{"Pattern": "sponsored"}},
+	}
+
+	for _, test := range tests {
+		_, err := GetRegistry().GetNewProcessor(test.name, test.params)
+		if err == nil {
+			t.Errorf("Expected error for %v with parameters %v", test.name, test.params)
+		}
+	}
+}
+
+type upperCaser struct{}
+
+func (p upperCaser) Process(context *Context) (*Context, error) {
+	context.Result["upper"] = "true"
+	return context, nil
+}
+
+type prefixer struct {
+	prefix string
+}
+
+func (p *prefixer) Configure(params Params) error {
+	var err error
+	p.prefix, err = params.String("Prefix")
+	return err
+}
+
+func (p *prefixer) Process(context *Context) (*Context, error) {
+	context.Result["prefix"] = p.prefix
+	return context, nil
+}
+
+func TestRegister(t *testing.T) {
+	defer func(r *Registry) { registered = r }(registered)
+	registered = &Registry{processors: make(map[string]reflect.Type)}
+
+	err := Register("UpperCaser", upperCaser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Register("Prefixer", &prefixer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"UpperCaser", "ExternalLinkRemover", ""} {
+		if Register(name, upperCaser{}) == nil {
+			t.Errorf("Expected error when registering processor with name %q", name)
+		}
+	}
+	if Register("Nil", nil) == nil {
+		t.Error("Expected error when registering nil processor")
+	}
+
+	reg := GetRegistry()
+	p, err := reg.GetNewProcessor("UpperCaser", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(upperCaser); !ok {
+		t.Errorf("Expected processor of type upperCaser, but got %v", reflect.TypeOf(p))
+	}
+
+	p, err = reg.GetNewProcessor("Prefixer", Params{"Prefix": "p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := p.Process(&Context{Result: make(map[string]string)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Result["prefix"] != "p" {
+		t.Errorf("Expected configured processor, but got %v", ctx.Result)
+	}
+}
+
+func TestGetNewProcessorWithUnknownName(t *testing.T) {
+	_, err := GetRegistry().GetNewProcessor("Unknown", nil)
+	if err == nil {
+		t.Error("Expected error for unknown processor")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	var doc interface{}
+	err := json.Unmarshal([]byte(`{"data": {"items": [{"tags": [{"name": "a"}, {"name": "b"}]}, {"tags": {"y": "d", "x": "c"}}]}}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want []interface{}
+	}{
+		{"data.items.0.tags.*.name", []interface{}{"a", "b"}},
+		{"data.items.1.tags.*", []interface{}{"c", "d"}},
+		{"data.items.*.tags.1.name", []interface{}{"b"}},
+		{"data.items.2", []interface{}{}},
+		{"data.missing", []interface{}{}},
+	}
+
+	for _, test := range tests {
+		got := Lookup(doc, test.path)
+		if len(got) != len(test.want) || (len(got) > 0 && !reflect.DeepEqual(got, test.want)) {
+			t.Errorf("Expected %v for path %v, but got %v", test.want, test.path, got)
+		}
+	}
+	if got := Lookup(doc, ""); !reflect.DeepEqual(got, []interface{}{doc}) {
+		t.Errorf("Expected document for empty path, but got %v", got)
+	}
+}
+
+func render(t *testing.T, ctx *Context) string {
+	var b bytes.Buffer
+	err := html.Render(&b, ctx.Content.(*html.Node))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
 func getContext(content string) (*Context, error) {
 	r := strings.NewReader(content)
 	doc, err := html.Parse(r)
@@ -0,0 +1,235 @@
+package processor
+
+import (
+	"bytes"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Readability fetches the article at the context's URL (see Context.Fetch) and
+// replaces the content with the article's main body, found using readability-style
+// heuristics. The HTML of the body, an excerpt of its first paragraphs and, unless
+// another processor found one, an image are provided as results. Content is left
+// unchanged if the article can't be fetched or no main body is found.
+//
+// Optional parameters are MaxContentSize (in bytes, defaults to 5MB) and
+// ExcerptLength (in characters, defaults to 300).
+type Readability struct {
+	maxContentSize int
+	excerptLength  int
+}
+
+// Elements which never hold the main content
+const readabilityIgnored = "script, style, noscript, iframe, form, nav, header, footer, aside, button, input, select, textarea"
+
+var (
+	readabilityPositive   = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|blog|story`)
+	readabilityNegative   = regexp.MustCompile(`(?i)comment|meta|footer|footnote|sidebar|sponsor|share|social|related|nav|promo|banner|ad-|advert|masthead|widget|popup`)
+	readabilityWhitespace = regexp.MustCompile(`\s+`)
+)
+
+// Configure the size of articles and excerpts
+func (p *Readability) Configure(params Params) error {
+	var err error
+	if p.maxContentSize, err = params.Int("MaxContentSize", 5*1024*1024); err != nil {
+		return err
+	}
+	if p.excerptLength, err = params.Int("ExcerptLength", 300); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Process the provided content
+func (p *Readability) Process(context *Context) (*Context, error) {
+	if !context.HTML || context.URL == "" || context.Fetch == nil {
+		return context, nil
+	}
+	base, err := url.Parse(context.URL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return context, nil
+	}
+
+	body, err := context.Fetch(context.URL)
+	if err != nil {
+		return context, nil
+	}
+	if len(body) > p.maxContentSize {
+		body = body[:p.maxContentSize]
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return context, nil
+	}
+	image := findPageImage(doc, context.URL)
+
+	article := findMainContent(doc)
+	if article == nil {
+		return context, nil
+	}
+	resolveURLs(article, base)
+
+	articleHTML, err := article.Html()
+	if err != nil {
+		return context, nil
+	}
+
+	context.Content = article.Get(0)
+	context.Result["html"] = strings.TrimSpace(articleHTML)
+	context.Result["excerpt"] = excerpt(article, p.excerptLength)
+	if context.Result["image"] == "" {
+		if image == "" {
+			image = findImage(article.Get(0), context.URL)
+		}
+		if image != "" {
+			context.Result["image"] = image
+		}
+	}
+	return context, nil
+}
+
+// findPageImage returns the absolute URL of the page's preview image (og:image), if any
+func findPageImage(doc *goquery.Document, base string) string {
+	image := ""
+	doc.Find(`meta[property="og:image"], meta[property="og:image:url"], meta[property="og:image:secure_url"], meta[name="twitter:image"]`).
+		Each(func(_ int, s *goquery.Selection) {
+			image = PreferHTTPS(image, ResolveImageURL(base, s.AttrOr("content", "")))
+		})
+	return image
+}
+
+// findMainContent returns the element most likely holding the article's main
+// body. Paragraphs contribute to the score of their parent (and half of it to
+// their grandparent) based on their length and number of commas. Scores are
+// adjusted by the class names and ids of elements, and by the share of text
+// in links.
+func findMainContent(doc *goquery.Document) *goquery.Selection {
+	doc.Find(readabilityIgnored).Remove()
+
+	scores := make(map[*html.Node]float64)
+	candidates := make([]*html.Node, 0)
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = classWeight(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	doc.Find("p, pre, td").Each(func(_ int, s *goquery.Selection) {
+		text := strings.TrimSpace(s.Text())
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		parent := s.Get(0).Parent
+		addScore(parent, score)
+		if parent != nil {
+			addScore(parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(goquery.NewDocumentFromNode(n).Selection))
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return goquery.NewDocumentFromNode(best).Selection
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, a := range n.Attr {
+		if a.Key != "class" && a.Key != "id" {
+			continue
+		}
+		if readabilityNegative.MatchString(a.Val) {
+			weight -= 25
+		}
+		if readabilityPositive.MatchString(a.Val) {
+			weight += 25
+		}
+	}
+	if n.Data == "article" || n.Data == "main" {
+		weight += 25
+	}
+	return weight
+}
+
+// linkDensity returns the share of the element's text within links
+func linkDensity(s *goquery.Selection) float64 {
+	length := len(s.Text())
+	if length == 0 {
+		return 0
+	}
+	linkLength := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		linkLength += len(a.Text())
+	})
+	return float64(linkLength) / float64(length)
+}
+
+// excerpt returns the text of the article's first paragraphs, shortened to about
+// length characters at a word boundary
+func excerpt(article *goquery.Selection, length int) string {
+	paragraphs := make([]string, 0)
+	size := 0
+	article.Find("p").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		text := strings.TrimSpace(readabilityWhitespace.ReplaceAllString(s.Text(), " "))
+		if text != "" {
+			paragraphs = append(paragraphs, text)
+			size += len(text)
+		}
+		return size < length
+	})
+
+	text := strings.Join(paragraphs, " ")
+	if text == "" {
+		text = strings.TrimSpace(readabilityWhitespace.ReplaceAllString(article.Text(), " "))
+	}
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	text = string(runes[:length])
+	if cut := strings.LastIndex(text, " "); cut > 0 {
+		text = text[:cut]
+	}
+	return strings.TrimRight(text, " ,.;:") + "..."
+}
+
+// resolveURLs makes the links and image sources of the article absolute
+func resolveURLs(article *goquery.Selection, base *url.URL) {
+	for _, attr := range []string{"href", "src"} {
+		article.Find("[" + attr + "]").Each(func(_ int, s *goquery.Selection) {
+			s.SetAttr(attr, resolveURL(base, s.AttrOr(attr, "")))
+		})
+	}
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
@@ -0,0 +1,128 @@
+package processor
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const article = `<html><head><meta property="og:image" content="/images/og.png"></head><body>
+<header><a href="/">Home</a></header>
+<nav><a href="/sports">Sports</a><a href="/tech">Tech</a></nav>
+<div class="sidebar"><p>Subscribe to our newsletter, for news, deals, and more.</p></div>
+<div class="article-body">
+<p>The moon will momentarily block Venus, then Mars and then Mercury, offering a vivid reminder.</p>
+<img src="/images/moon.jpg">
+<p>The occultation, as astronomers call it, will be visible from parts of Europe, Asia and Africa.</p>
+<p>Read the <a href="/related">related story</a> for details on the orbits, timing, and visibility.</p>
+</div>
+<div class="comments"><p>Great article, thanks for sharing, loved it, will share.</p></div>
+<footer><p>Copyright 2017, all rights reserved, some other text.</p></footer>
+</body></html>`
+
+func TestReadability(t *testing.T) {
+	const base = "http://example.com"
+	var fetched []string
+	p, err := GetRegistry().GetNewProcessor("Readability", Params{"ExcerptLength": int64(120)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := getContext("<p>Teaser</p>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx.URL = base + "/article"
+	ctx.Fetch = func(pageURL string) ([]byte, error) {
+		fetched = append(fetched, pageURL)
+		return []byte(article), nil
+	}
+	ctx, err = p.Process(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fetched, []string{base + "/article"}) {
+		t.Errorf("Expected article to be fetched once, but got %v", fetched)
+	}
+	if ctx.Content.(*html.Node).Data != "div" {
+		t.Errorf("Expected content to be replaced by the article body, but got %v", ctx.Content.(*html.Node).Data)
+	}
+	got := ctx.Result["html"]
+	if !strings.HasPrefix(got, "<p>The moon") || !strings.Contains(got, `<img src="`+base+`/images/moon.jpg"/>`) ||
+		!strings.Contains(got, `<a href="`+base+`/related">`) || strings.Contains(got, "newsletter") || strings.Contains(got, "Great article") {
+		t.Errorf("Expected article body with absolute URLs, but got %v", got)
+	}
+	want := "The moon will momentarily block Venus, then Mars and then Mercury, offering a vivid reminder. The occultation, as..."
+	if ctx.Result["excerpt"] != want {
+		t.Errorf("Expected excerpt %q, but got %q", want, ctx.Result["excerpt"])
+	}
+	if ctx.Result["image"] != base+"/images/og.png" {
+		t.Errorf("Expected page image, but got %v", ctx.Result["image"])
+	}
+}
+
+func TestReadabilityAfterOtherProcessors(t *testing.T) {
+	fetches := 0
+	registry := GetRegistry()
+	var chain []Processor
+	for _, name := range []string{"ExternalLinkRemover", "BoldElementRemover", "Readability"} {
+		p, err := registry.GetNewProcessor(name, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain = append(chain, p)
+	}
+
+	ctx, err := getContext(`<p>Teaser <a href="/related">related</a> <b>bold</b></p>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx.URL = "http://example.com/article"
+	ctx.Fetch = func(pageURL string) ([]byte, error) {
+		fetches++
+		return []byte(article), nil
+	}
+	for _, p := range chain {
+		ctx, err = p.Process(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fetches != 1 || !strings.HasPrefix(ctx.Result["html"], "<p>The moon") {
+		t.Errorf("Expected article to be extracted after other processors, but got %v fetches and %v", fetches, ctx.Result["html"])
+	}
+}
+
+func TestReadabilityKeepsContentIfArticleIsUnavailable(t *testing.T) {
+	p, err := GetRegistry().GetNewProcessor("Readability", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetchers := map[string]func(string) ([]byte, error){
+		"missing": nil,
+		"failing": func(string) ([]byte, error) { return nil, errors.New("404 Not Found") },
+	}
+	for name, fetch := range fetchers {
+		ctx, err := getContext("<p>Teaser</p>")
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := ctx.Content
+		ctx.URL = "http://example.com/article"
+		ctx.Fetch = fetch
+		ctx.Result["image"] = "http://example.com/image.png"
+		ctx, err = p.Process(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ctx.Content != content || ctx.Result["html"] != "" || ctx.Result["image"] != "http://example.com/image.png" {
+			t.Errorf("Expected content to remain unchanged with %v fetcher, but got %v", name, ctx.Result)
+		}
+	}
+}
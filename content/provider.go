@@ -1,16 +1,29 @@
 package content
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"log"
+	"net/http"
 	"os"
 	"strings"
 
 	"path/filepath"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"mozilla.org/crec/content/processor"
 )
 
+// Content formats of providers (see Provider.Format)
+const (
+	FormatNative   = "native"
+	FormatFeed     = "feed"
+	FormatJSONFeed = "jsonfeed"
+	FormatJSON     = "json"
+)
+
 // Provider represents a content provider.
 type Provider struct {
 	// Unique system-wide identifier of this provider.
@@ -31,12 +44,26 @@ type Provider struct {
 	// Chain of content processor names, executed in declaration order.
 	Processors []string
 
+	// Parameters of configurable processors, keyed by the name used in
+	// Processors. The processor's type is specified using the Type parameter,
+	// defaulting to the name, so the same type can be used multiple times.
+	ProcessorConfig map[string]processor.Params
+
 	// Chain of content processors instances, executed in declaration order.
 	processors []processor.Processor
 
 	// Native indicates whether or not this provider uses our content format.
 	Native bool
 
+	// Specifies the format of this provider's content: native (our format),
+	// feed (RSS or Atom), jsonfeed (JSON Feed) or json (arbitrary JSON, see
+	// Mapping). If omitted, the format is detected when content is ingested,
+	// unless Native or Mapping is set.
+	Format string
+
+	// Declares where to find content in JSON of the json format.
+	Mapping *FieldMapping
+
 	// Specifies the default applicable regions for this provider’s content.
 	// If omitted, content will be considered for all regions, unless
 	// specified otherwise in content.
@@ -56,6 +83,68 @@ type Provider struct {
 	// should be refreshed.
 	MaxContentAge int
 
+	// Specifies the interval in minutes at which the scheduler refreshes this
+	// provider's content. If omitted, MaxContentAge is used, or the configured
+	// index refresh interval if neither is present.
+	RefreshInterval int
+
+	// Specifies a cron expression (minute, hour, day of month, month and day
+	// of week) for refreshing this provider's content e.g. "*/10 * * * *" or
+	// "0 6 * * 1". Takes precedence over RefreshInterval.
+	Schedule string
+
+	// Specifies the maximum random delay in seconds added to every scheduled
+	// refresh, to spread out requests.
+	Jitter int
+
+	// Parsed refresh schedule, nil if the default interval applies.
+	schedule Schedule
+
+	// Specifies the maximum age in hours of this provider's content items,
+	// based on their publication date. Older items are not ingested, items
+	// without a publication date are always ingested.
+	MaxItemAge int
+
+	// Specifies the timeout in seconds for fetching content from ContentURL.
+	// Defaults to 5 seconds.
+	Timeout int
+
+	// Specifies the user agent sent when fetching content. If omitted, Go's
+	// default user agent is used.
+	UserAgent string
+
+	// Specifies additional HTTP headers sent when fetching content.
+	Headers map[string]string
+
+	// Specify the names of the environment variables holding the credentials
+	// for basic authentication, so secrets can be kept out of the registry.
+	BasicAuthUserEnv     string
+	BasicAuthPasswordEnv string
+
+	// Specifies the name of the environment variable holding a bearer token
+	// sent in the Authorization header when fetching content.
+	BearerTokenEnv string
+
+	// Specifies the maximum size in bytes of the fetched content. If omitted,
+	// the size is not limited.
+	MaxContentSize int64
+
+	// Specifies whether missing images, authors, publication dates, tags and
+	// languages of fetched content should be filled in using the metadata
+	// (Open Graph, Twitter cards and JSON-LD) of the linked pages.
+	EnrichMetadata bool
+
+	// Specifies query parameters used for tracking, which are removed from
+	// the URLs of this provider's content in addition to common ones such as
+	// utm_source. A trailing * matches any suffix.
+	TrackingParams []string
+
+	// Specifies the priority of this provider's content over near-duplicates
+	// (e.g. the same wire story) from other providers. The content of the
+	// provider with the highest priority is recommended, the others are made
+	// available as its alternates. Defaults to 0.
+	Priority int
+
 	// Specifies the default domain similarities of this provider. The domain
 	// name is used as key, the weight as value. This can be used on the client
 	// to map content of this provider to specific user interests i.e. based on
@@ -63,6 +152,27 @@ type Provider struct {
 	Domains map[string]float32
 }
 
+// ProviderStatus keeps track of failed content fetches, so that providers which
+// are failing repeatedly can be skipped for a cool-down period.
+type ProviderStatus struct {
+	// Number of consecutive failed attempts to fetch content.
+	ConsecutiveFailures int `json:"consecutive_failures"`
+
+	// Error of the last failed attempt.
+	LastError string `json:"last_error,omitempty"`
+
+	// Time of the last failed attempt.
+	LastFailure time.Time `json:"last_failure"`
+
+	// Time until which no content is fetched from this provider.
+	TrippedUntil time.Time `json:"tripped_until"`
+}
+
+// Tripped returns true if fetching content from this provider is currently suspended.
+func (s ProviderStatus) Tripped() bool {
+	return time.Now().Before(s.TrippedUntil)
+}
+
 // Providers is a mapping of provider IDs to instances
 type Providers map[string]*Provider
 
@@ -99,19 +209,167 @@ func readProvidersFromRegistry(providerDir string) (Providers, error) {
 	providerMap := make(map[string]*Provider)
 	registry := processor.GetRegistry()
 	for _, provider := range providers {
-		if len(provider.Processors) > 0 {
-			provider.processors = make([]processor.Processor, 0)
-			for _, name := range provider.Processors {
-				provider.processors = append(provider.processors, registry.GetNewProcessor(name))
-			}
+		err := provider.init(registry)
+		if err != nil {
+			log.Printf("Disabling provider %v: %v", provider.ID, err)
+			continue
 		}
-
 		providerMap[provider.ID] = provider
 	}
 
 	return providerMap, nil
 }
 
+// init validates the provider's configuration and creates its processors and schedule
+func (p *Provider) init(registry *processor.Registry) error {
+	err := p.createProcessors(registry)
+	if err != nil {
+		return err
+	}
+
+	switch p.GetFormat() {
+	case "", FormatNative, FormatFeed, FormatJSONFeed:
+	case FormatJSON:
+		err = p.Mapping.validate()
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Invalid format %v, expected one of %v, %v, %v or %v",
+			p.Format, FormatNative, FormatFeed, FormatJSONFeed, FormatJSON)
+	}
+
+	schedule, err := p.parseSchedule()
+	if err != nil {
+		return fmt.Errorf("Invalid schedule: %v", err)
+	}
+	p.schedule = schedule
+	return nil
+}
+
+// createProcessors instantiates the provider's chain of content processors
+func (p *Provider) createProcessors(registry *processor.Registry) error {
+	if len(p.Processors) == 0 {
+		return nil
+	}
+
+	p.processors = make([]processor.Processor, 0)
+	for _, name := range p.Processors {
+		params := p.ProcessorConfig[name]
+		processorType, err := params.String("Type")
+		if err != nil {
+			return fmt.Errorf("Invalid processor %v: %v", name, err)
+		}
+		if processorType == "" {
+			processorType = name
+		}
+
+		proc, err := registry.GetNewProcessor(processorType, params)
+		if err != nil {
+			return fmt.Errorf("Invalid processor %v: %v", name, err)
+		}
+		p.processors = append(p.processors, proc)
+	}
+	return nil
+}
+
+// defaultTimeout is used when fetching content from providers without a configured timeout
+const defaultTimeout = time.Second * 5
+
+// GetTimeout returns the timeout for fetching content from this provider
+func (p *Provider) GetTimeout() time.Duration {
+	if p.Timeout > 0 {
+		return time.Second * time.Duration(p.Timeout)
+	}
+	return defaultTimeout
+}
+
+// GetSchedule returns the schedule for refreshing this provider's content, using
+// the provided default interval if no schedule or interval is configured
+func (p *Provider) GetSchedule(defaultInterval time.Duration) Schedule {
+	if p.schedule != nil {
+		return p.schedule
+	}
+	return IntervalSchedule(defaultInterval)
+}
+
+func (p *Provider) parseSchedule() (Schedule, error) {
+	if p.Schedule != "" {
+		return ParseCron(p.Schedule)
+	}
+	if p.RefreshInterval > 0 {
+		return IntervalSchedule(time.Minute * time.Duration(p.RefreshInterval)), nil
+	}
+	if p.MaxContentAge > 0 {
+		return IntervalSchedule(time.Minute * time.Duration(p.MaxContentAge)), nil
+	}
+	return nil, nil
+}
+
+// prepareRequest adds the configured headers, user agent and credentials to the request
+func (p *Provider) prepareRequest(req *http.Request) error {
+	for name, value := range p.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if p.UserAgent != "" {
+		req.Header.Set("User-Agent", p.UserAgent)
+	}
+
+	if p.BasicAuthUserEnv != "" {
+		user, err := lookupEnv(p.BasicAuthUserEnv)
+		if err != nil {
+			return err
+		}
+		password := ""
+		if p.BasicAuthPasswordEnv != "" {
+			password, err = lookupEnv(p.BasicAuthPasswordEnv)
+			if err != nil {
+				return err
+			}
+		}
+		req.SetBasicAuth(user, password)
+	}
+
+	if p.BearerTokenEnv != "" {
+		token, err := lookupEnv(p.BearerTokenEnv)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+func lookupEnv(name string) (string, error) {
+	val, ok := os.LookupEnv(name)
+	if !ok {
+		return "", errors.New("Environment variable " + name + " not set")
+	}
+	return val, nil
+}
+
+// GetMaxItemAge returns the maximum age of this provider's content items, zero if unlimited
+func (p *Provider) GetMaxItemAge() time.Duration {
+	return time.Hour * time.Duration(p.MaxItemAge)
+}
+
+// GetTrackingParams returns the query parameters removed from URLs of this provider's content
+func (p *Provider) GetTrackingParams() []string {
+	return append(append([]string{}, defaultTrackingParams...), p.TrackingParams...)
+}
+
+// GetFormat returns the format of this provider's content, empty if it should be detected
+func (p *Provider) GetFormat() string {
+	if p.Native {
+		return FormatNative
+	}
+	if p.Format == "" && p.Mapping != nil {
+		return FormatJSON
+	}
+	return p.Format
+}
+
 // GetProcessors returns the configured chain of content processors
 func (p *Provider) GetProcessors() []processor.Processor {
 	return p.processors
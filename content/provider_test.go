@@ -1,8 +1,15 @@
 package content
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"mozilla.org/crec/content/processor"
 )
 
 var providerDir string
@@ -42,6 +49,7 @@ func TestGetProviders(t *testing.T) {
 		Regions:       []string{"r1", "r2"},
 		Script:        "s1",
 		MaxContentAge: 1,
+		schedule:      IntervalSchedule(time.Minute),
 		Domains:       map[string]float32{"bbc.co.uk": 0.9, "news.google.com": 0.8}}
 	got := providers["p1"]
 
@@ -59,6 +67,7 @@ func TestGetProviders(t *testing.T) {
 		Regions:       []string{"r1", "r2"},
 		Script:        "s1",
 		MaxContentAge: 1,
+		schedule:      IntervalSchedule(time.Minute),
 		Domains:       map[string]float32{"bbc.co.uk": 0.9, "news.google.com": 0.8}}
 	got = providers["p2"]
 
@@ -67,3 +76,95 @@ func TestGetProviders(t *testing.T) {
 	}
 
 }
+
+func TestGetSchedule(t *testing.T) {
+	p := &Provider{Schedule: "0 6 * * 1"}
+	schedule, err := p.parseSchedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schedule.(*CronSchedule); !ok {
+		t.Errorf("Expected cron schedule, but got %v", schedule)
+	}
+
+	p = &Provider{RefreshInterval: 2, MaxContentAge: 10}
+	schedule, _ = p.parseSchedule()
+	if schedule != IntervalSchedule(2*time.Minute) {
+		t.Errorf("Expected refresh interval to take precedence, but got %v", schedule)
+	}
+
+	p = &Provider{}
+	p.schedule, _ = p.parseSchedule()
+	if p.GetSchedule(time.Hour) != IntervalSchedule(time.Hour) {
+		t.Errorf("Expected default interval, but got %v", p.GetSchedule(time.Hour))
+	}
+
+	p = &Provider{Schedule: "every minute"}
+	_, err = p.parseSchedule()
+	if err == nil {
+		t.Error("Expected invalid schedule to be rejected")
+	}
+}
+
+func TestCreateProcessors(t *testing.T) {
+	var p Provider
+	_, err := toml.Decode(`
+ID = "p"
+Processors = ["ExternalLinkRemover", "ads", "ElementRemover"]
+
+[ProcessorConfig.ads]
+Type = "ElementRemover"
+Selectors = ["div.ad"]
+
+[ProcessorConfig.ElementRemover]
+Selectors = ["aside"]
+`, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.createProcessors(processor.GetRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.processors) != 3 {
+		t.Fatalf("Expected 3 processors, but got %v", len(p.processors))
+	}
+	if _, ok := p.processors[1].(*processor.ElementRemover); !ok {
+		t.Errorf("Expected processor of type ElementRemover, but got %v", reflect.TypeOf(p.processors[1]))
+	}
+
+	p.ProcessorConfig["ads"]["Selectors"] = []interface{}{}
+	err = p.createProcessors(processor.GetRegistry())
+	if err == nil {
+		t.Error("Expected error for processor with missing parameters")
+	}
+}
+
+func TestReadProvidersFromRegistryDisablesMisconfiguredProviders(t *testing.T) {
+	dir := filepath.FromSlash(os.TempDir() + "/crec-test-misconfigured-registry")
+	os.Mkdir(dir, 0777)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"valid.toml":    "ID=\"valid\"\nProcessors=[\"ExternalLinkRemover\"]",
+		"unknown.toml":  "ID=\"unknown\"\nProcessors=[\"ExternalLinkRemovr\"]",
+		"params.toml":   "ID=\"params\"\nProcessors=[\"ElementRemover\"]",
+		"format.toml":   "ID=\"format\"\nFormat=\"xml\"",
+		"schedule.toml": "ID=\"schedule\"\nSchedule=\"* *\"",
+	}
+	for name, provider := range files {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(provider), 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	providers, err := readProvidersFromRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(providers) != 1 || providers["valid"] == nil {
+		t.Errorf("Expected only the valid provider, but got %v", providers)
+	}
+}
@@ -0,0 +1,370 @@
+package content
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Prefix of files in the import queue holding pushed content
+	importFilePrefix = "import"
+	// Prefix of files in the import queue holding IDs of retracted content
+	retractionFilePrefix = "retract"
+	// Sub directory of the import queue holding successfully imported files
+	processedDir = "processed"
+	// Sub directory of the import queue holding files which failed to import
+	failedDir = "failed"
+	// File holding all content imported from a provider
+	queueStateFile = "state.json"
+)
+
+// queueState holds all content pushed by a provider, folded together from
+// all successfully processed imports. Retractions are kept indefinitely, so
+// retracted content doesn't reappear when it is fetched from the provider again.
+type queueState struct {
+	Items     []*queuedContent     `json:"items"`
+	Retracted map[string]time.Time `json:"retracted,omitempty"`
+}
+
+// queuedContent is a content item along with the time it was imported, and the
+// outcome of its analysis (see analyzeContent)
+type queuedContent struct {
+	Content  *Content  `json:"content"`
+	Imported time.Time `json:"imported"`
+	// Language of the content, which isn't part of its serialized form
+	Language string `json:"language,omitempty"`
+	Analyzed bool   `json:"analyzed,omitempty"`
+}
+
+// Enqueue writes content to the disc to be ingested in the next indexing iteration.
+// Existing content with the same ID is replaced.
+func Enqueue(config Config, content []byte, provider string) error {
+	return enqueue(config, content, provider, importFilePrefix)
+}
+
+// EnqueueRetraction writes the IDs of content to be retracted to the disc. The
+// content is removed in the next indexing iteration and won't be ingested again.
+func EnqueueRetraction(config Config, ids []string, provider string) error {
+	bytes, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return enqueue(config, bytes, provider, retractionFilePrefix)
+}
+
+func enqueue(config Config, data []byte, provider string, prefix string) error {
+	path := filepath.Join(config.GetImportQueueDir(), provider)
+	err := os.MkdirAll(path, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// Data is written to a hidden file first, so it isn't picked up
+	// by the ingester before it was written completely.
+	tmp, err := writeTempFile(path, prefix, data)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(path, strings.TrimPrefix(filepath.Base(tmp), ".")))
+}
+
+// ReplayFailedImports moves all imports of the given provider which failed to be
+// ingested back into the import queue, and returns the number of replayed imports.
+func ReplayFailedImports(config Config, provider string) (int, error) {
+	path := filepath.Join(config.GetImportQueueDir(), provider)
+	files, err := ioutil.ReadDir(filepath.Join(path, failedDir))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		err = os.Rename(filepath.Join(path, failedDir, f.Name()), filepath.Join(path, f.Name()))
+		if err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// ingestFromQueue processes all pending imports of the given provider and folds
+// them into the provider's queue state. It returns the provided content of the
+// provider merged with the queue state: pushed items replace items with the
+// same ID, retracted items are removed. Newly queued content is analyzed using
+// the statistics of the current index (see analyzeContent), and the analysis is
+// persisted along with it.
+func ingestFromQueue(config Config, provider *Provider, content []*Content, curIndex *Index) ([]*Content, error) {
+	path := filepath.Join(config.GetImportQueueDir(), provider.ID)
+	state, err := readQueueState(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := findPendingImports(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range pending {
+		err := processImport(filepath.Join(path, f.Name()), provider, state)
+		if err != nil {
+			log.Printf("Failed to import %v from provider %v: %v", f.Name(), provider.ID, err)
+			err = archiveImport(path, f.Name(), failedDir)
+		} else {
+			err = archiveImport(path, f.Name(), processedDir)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changed := len(pending) > 0
+	retention := config.GetImportRetention()
+	if retention > 0 {
+		expiry := time.Now().Add(-retention)
+		changed = state.expire(expiry) || changed
+		pruneImports(filepath.Join(path, processedDir), expiry)
+		pruneImports(filepath.Join(path, failedDir), expiry)
+	}
+
+	if state.analyze(config, provider, curIndex) {
+		changed = true
+	}
+
+	if changed {
+		err = writeQueueState(path, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state.apply(content), nil
+}
+
+// findPendingImports returns all files waiting in the import queue, oldest first
+func findPendingImports(path string) ([]os.FileInfo, error) {
+	files, err := ioutil.ReadDir(path)
+	if os.IsNotExist(err) {
+		return []os.FileInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files = _filter(files, func(f os.FileInfo) bool {
+		return !f.IsDir() && !strings.HasPrefix(f.Name(), ".") && f.Name() != queueStateFile
+	})
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	return files, nil
+}
+
+// processImport folds the pushed content or retractions of the import file into the queue state
+func processImport(file string, provider *Provider, state *queueState) error {
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(filepath.Base(file), retractionFilePrefix) {
+		var ids []string
+		err = json.Unmarshal(bytes, &ids)
+		if err != nil {
+			return err
+		}
+		state.retract(canonicalizeIDs(provider, ids), time.Now())
+		return nil
+	}
+
+	content, err := parseJSON(bytes, provider)
+	if err != nil {
+		return err
+	}
+	state.add(canonicalizeContent(provider, content), time.Now())
+	return nil
+}
+
+// archiveImport moves the import file into the given sub directory of the import queue
+func archiveImport(path string, name string, dir string) error {
+	err := os.MkdirAll(filepath.Join(path, dir), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(path, name), filepath.Join(path, dir, name))
+}
+
+// pruneImports deletes all archived imports older than the provided expiry time
+func pruneImports(dir string, expiry time.Time) {
+	files, _ := ioutil.ReadDir(dir)
+	for _, f := range files {
+		if !f.IsDir() && f.ModTime().Before(expiry) {
+			err := os.Remove(filepath.Join(dir, f.Name()))
+			if err != nil {
+				log.Println("Failed to prune archived import: ", err)
+			}
+		}
+	}
+}
+
+func readQueueState(path string) (*queueState, error) {
+	state := &queueState{Items: make([]*queuedContent, 0), Retracted: make(map[string]time.Time)}
+	bytes, err := ioutil.ReadFile(filepath.Join(path, queueStateFile))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(bytes, state)
+	if state.Retracted == nil {
+		state.Retracted = make(map[string]time.Time)
+	}
+	return state, err
+}
+
+// writeQueueState replaces the queue state atomically, so a crash doesn't leave a partial file
+func writeQueueState(path string, state *queueState) error {
+	bytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := writeTempFile(path, queueStateFile, bytes)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(path, queueStateFile))
+}
+
+// writeTempFile writes the data to a new hidden file in the provided directory and returns its path
+func writeTempFile(dir string, prefix string, data []byte) (string, error) {
+	f, err := ioutil.TempFile(dir, "."+prefix)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Write(data)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// add inserts the provided content, replacing existing items with the same ID.
+// Previously retracted content is published again.
+func (s *queueState) add(content []*Content, imported time.Time) {
+	items := make(map[string]int)
+	for i, item := range s.Items {
+		items[item.Content.ID] = i
+	}
+
+	for _, c := range content {
+		delete(s.Retracted, c.ID)
+		if i, ok := items[c.ID]; ok {
+			s.Items[i] = &queuedContent{Content: c, Imported: imported}
+		} else {
+			items[c.ID] = len(s.Items)
+			s.Items = append(s.Items, &queuedContent{Content: c, Imported: imported})
+		}
+	}
+}
+
+// retract removes the content with the provided IDs and remembers the retraction
+func (s *queueState) retract(ids []string, retracted time.Time) {
+	for _, id := range ids {
+		s.Retracted[id] = retracted
+	}
+
+	items := make([]*queuedContent, 0)
+	for _, item := range s.Items {
+		if _, ok := s.Retracted[item.Content.ID]; !ok {
+			items = append(items, item)
+		}
+	}
+	s.Items = items
+}
+
+// analyze analyzes all items which weren't analyzed yet, and restores the analysis
+// of the others. It returns true if any items were analyzed.
+func (s *queueState) analyze(config Config, provider *Provider, curIndex *Index) bool {
+	unanalyzed := make([]*Content, 0)
+	for _, item := range s.Items {
+		if item.Analyzed {
+			item.Content.Language = item.Language
+		} else {
+			unanalyzed = append(unanalyzed, item.Content)
+		}
+	}
+	if len(unanalyzed) == 0 {
+		return false
+	}
+
+	analyzeContent(config, provider, curIndex, unanalyzed)
+	for _, item := range s.Items {
+		item.Language = item.Content.Language
+		item.Analyzed = true
+	}
+	return true
+}
+
+// apply merges the queue state into the provided content. Pushed items replace
+// items with the same ID, the remaining pushed items are appended and retracted
+// items are removed.
+func (s *queueState) apply(content []*Content) []*Content {
+	pushed := make(map[string]*Content)
+	for _, item := range s.Items {
+		pushed[item.Content.ID] = item.Content
+	}
+
+	merged := make([]*Content, 0)
+	for _, c := range content {
+		if _, ok := s.Retracted[c.ID]; ok {
+			continue
+		}
+		if p, ok := pushed[c.ID]; ok {
+			merged = append(merged, p)
+			delete(pushed, c.ID)
+		} else {
+			merged = append(merged, c)
+		}
+	}
+	for _, item := range s.Items {
+		if _, ok := pushed[item.Content.ID]; ok {
+			merged = append(merged, item.Content)
+		}
+	}
+	return merged
+}
+
+// expire removes all items imported before the provided expiry time and
+// returns true if any items were removed
+func (s *queueState) expire(expiry time.Time) bool {
+	items := make([]*queuedContent, 0)
+	for _, item := range s.Items {
+		if !item.Imported.Before(expiry) {
+			items = append(items, item)
+		}
+	}
+	expired := len(items) < len(s.Items)
+	s.Items = items
+	return expired
+}
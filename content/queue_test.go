@@ -0,0 +1,179 @@
+package content
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIngestFromQueueArchivesImports(t *testing.T) {
+	config := &TestConfig{}
+	provider := &Provider{ID: "test-archive"}
+	path := filepath.Join(config.GetImportQueueDir(), provider.ID)
+	defer os.RemoveAll(path)
+
+	Enqueue(config, []byte(`[{"id":"0"}]`), provider.ID)
+	Enqueue(config, []byte(`invalid`), provider.ID)
+
+	content, err := ingestFromQueue(config, provider, []*Content{}, createIndexWithID(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 1 {
+		t.Errorf("Expected valid import to be ingested, but got %v", content)
+	}
+
+	assertFileCount(t, path, 0)
+	assertFileCount(t, filepath.Join(path, processedDir), 1)
+	assertFileCount(t, filepath.Join(path, failedDir), 1)
+
+	replayed, err := ReplayFailedImports(config, provider.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != 1 {
+		t.Errorf("Expected exactly 1 replayed import, but got %v", replayed)
+	}
+	assertFileCount(t, path, 1)
+	assertFileCount(t, filepath.Join(path, failedDir), 0)
+}
+
+func TestIngestFromQueueRetainsImportedContent(t *testing.T) {
+	config := &TestConfig{}
+	provider := &Provider{ID: "test-retain"}
+	path := filepath.Join(config.GetImportQueueDir(), provider.ID)
+	defer os.RemoveAll(path)
+
+	Enqueue(config, []byte(`[{"id":"0", "title":"t0"}, {"id":"1"}]`), provider.ID)
+	_, err := ingestFromQueue(config, provider, []*Content{}, createIndexWithID(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Enqueue(config, []byte(`[{"id":"0", "title":"t1"}]`), provider.ID)
+	content, err := ingestFromQueue(config, provider, []*Content{}, createIndexWithID(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(content) != 2 {
+		t.Fatalf("Expected previously imported content to be retained, but got %v", content)
+	}
+	if content[0].ID != "0" || content[0].Title != "t1" {
+		t.Errorf("Expected content with ID 0 to be updated, but got %v", content[0])
+	}
+}
+
+func TestQueueStateExpiresContent(t *testing.T) {
+	now := time.Now()
+	state := &queueState{}
+	state.add([]*Content{{ID: "0"}}, now.Add(-time.Hour*2))
+	state.add([]*Content{{ID: "1"}}, now)
+
+	if !state.expire(now.Add(-time.Hour)) {
+		t.Error("Expected content to be expired")
+	}
+	content := state.apply([]*Content{})
+	if len(content) != 1 || content[0].ID != "1" {
+		t.Errorf("Expected only content with ID 1 to be retained, but got %v", content)
+	}
+	if state.expire(now.Add(-time.Hour)) {
+		t.Error("Expected no further content to be expired")
+	}
+}
+
+func TestIngestFromQueuePersistsAnalysis(t *testing.T) {
+	config := &TestConfig{}
+	provider := &Provider{ID: "test-analysis"}
+	path := filepath.Join(config.GetImportQueueDir(), provider.ID)
+	defer os.RemoveAll(path)
+
+	Enqueue(config, []byte(`[{"id":"0", "title":"The weather this week", "excerpt":"It will be sunny and warm for most of the week, with rain expected on the weekend."}]`), provider.ID)
+	content, err := ingestFromQueue(config, provider, []*Content{}, createIndexWithID(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 1 || content[0].Language != "en" {
+		t.Fatalf("Expected language of queued content to be detected, but got %v", content)
+	}
+
+	state, err := readQueueState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Items[0].Analyzed || state.Items[0].Language != "en" {
+		t.Fatalf("Expected analysis to be persisted, but got %+v", state.Items[0])
+	}
+
+	// Analyzed content isn't analyzed again
+	state.Items[0].Language = "fr"
+	err = writeQueueState(path, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err = ingestFromQueue(config, provider, []*Content{}, createIndexWithID(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content[0].Language != "fr" {
+		t.Errorf("Expected persisted language to be restored, but got %v", content[0].Language)
+	}
+}
+
+func assertFileCount(t *testing.T, dir string, want int) {
+	files, _ := ioutil.ReadDir(dir)
+	files = _filter(files, func(f os.FileInfo) bool {
+		return !f.IsDir() && f.Name() != queueStateFile
+	})
+	if len(files) != want {
+		t.Errorf("Expected %v files in %v, but found %v", want, dir, len(files))
+	}
+}
+
+func TestIngestFromQueueAppliesRetractionsAndUpdates(t *testing.T) {
+	config := &TestConfig{}
+	provider := &Provider{ID: "test-retract"}
+	path := filepath.Join(config.GetImportQueueDir(), provider.ID)
+	defer os.RemoveAll(path)
+
+	fetched := []*Content{{ID: "0"}, {ID: "1", Title: "t0"}, {ID: "2"}}
+	Enqueue(config, []byte(`[{"id":"1", "title":"t1"}, {"id":"3"}]`), provider.ID)
+	EnqueueRetraction(config, []string{"0", "3"}, provider.ID)
+
+	content, err := ingestFromQueue(config, provider, fetched, createIndexWithID(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, content, "1", "2")
+	if content[0].Title != "t1" {
+		t.Errorf("Expected content with ID 1 to be replaced by pushed content, but got %v", content[0])
+	}
+
+	// Retractions are retained when content is fetched again
+	content, err = ingestFromQueue(config, provider, fetched, createIndexWithID(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, content, "1", "2")
+
+	// Pushing retracted content publishes it again
+	Enqueue(config, []byte(`[{"id":"3"}]`), provider.ID)
+	content, err = ingestFromQueue(config, provider, fetched, createIndexWithID(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, content, "1", "2", "3")
+}
+
+func assertContentIDs(t *testing.T, content []*Content, ids ...string) {
+	if len(content) != len(ids) {
+		t.Fatalf("Expected content with IDs %v, but got %v", ids, content)
+	}
+	for i, id := range ids {
+		if content[i].ID != id {
+			t.Errorf("Expected content with ID %v at position %v, but got %v", id, i, content[i].ID)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package content
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Maximum number of rejected items (and failed pages) listed in a report, further
+// rejections are only counted
+const maxReportedRejections = 100
+
+// Report summarizes an attempt to ingest content from a provider
+type Report struct {
+	// Identifier of the content provider
+	Provider string `json:"provider"`
+
+	// How the content was ingested, fetched from the provider's ContentURL or
+	// pushed by its WebSub hub
+	Type string `json:"type"`
+
+	// Start time and duration of the attempt
+	Started          time.Time `json:"started"`
+	DurationInMillis int64     `json:"duration_ms"`
+
+	// HTTP status of the provider's response, if any
+	Status int `json:"status,omitempty"`
+
+	// Number of items accepted and the items rejected. Only the first rejected
+	// items are listed, the number of others is RejectedTruncated.
+	Accepted          int         `json:"accepted"`
+	Rejected          []Rejection `json:"rejected,omitempty"`
+	RejectedTruncated int         `json:"rejected_truncated,omitempty"`
+
+	// Pages linked by the items which couldn't be fetched for processing them
+	// e.g. articles, limited like rejected items. Items are accepted nonetheless.
+	FailedPages          []PageFailure `json:"failed_pages,omitempty"`
+	FailedPagesTruncated int           `json:"failed_pages_truncated,omitempty"`
+
+	// Reason the attempt failed, if it did
+	Error string `json:"error,omitempty"`
+}
+
+// Rejection explains why an item was skipped during ingestion
+type Rejection struct {
+	ID     string `json:"id,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// PageFailure explains why a page linked by an item couldn't be fetched
+type PageFailure struct {
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// Types of ingestion attempts
+const (
+	reportTypeFetch = "fetch"
+	reportTypePush  = "push"
+)
+
+func newReport(provider *Provider, reportType string) *Report {
+	return &Report{Provider: provider.ID, Type: reportType, Started: time.Now()}
+}
+
+// reject records an item which was skipped
+func (r *Report) reject(id string, err error) {
+	if len(r.Rejected) >= maxReportedRejections {
+		r.RejectedTruncated++
+		return
+	}
+	r.Rejected = append(r.Rejected, Rejection{ID: id, Reason: err.Error()})
+}
+
+// failPage records a linked page which couldn't be fetched
+func (r *Report) failPage(pageURL string, err error) {
+	if len(r.FailedPages) >= maxReportedRejections {
+		r.FailedPagesTruncated++
+		return
+	}
+	r.FailedPages = append(r.FailedPages, PageFailure{URL: pageURL, Reason: err.Error()})
+}
+
+// rejections returns the number of items which were skipped
+func (r *Report) rejections() int {
+	return len(r.Rejected) + r.RejectedTruncated
+}
+
+// finish records the outcome of the attempt
+func (r *Report) finish(content []*Content, err error) {
+	r.DurationInMillis = int64(time.Since(r.Started) / time.Millisecond)
+	if err != nil {
+		r.Error = err.Error()
+	} else if r.Status != http.StatusNotModified {
+		r.Accepted = len(content)
+	}
+}
+
+// Reports keeps the most recent ingestion reports in memory
+type Reports struct {
+	reports []Report
+	next    int
+	full    bool
+	mux     sync.Mutex
+}
+
+// NewReports creates a ring buffer holding up to limit reports
+func NewReports(limit int) *Reports {
+	if limit < 0 {
+		limit = 0
+	}
+	return &Reports{reports: make([]Report, limit)}
+}
+
+// Add records the report, replacing the oldest one if the limit is reached
+func (r *Reports) Add(report Report) {
+	if r == nil || len(r.reports) == 0 {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.reports[r.next] = report
+	r.next = (r.next + 1) % len(r.reports)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Get returns the reports of the provider, or of all providers if empty,
+// starting with the most recent
+func (r *Reports) Get(provider string) []Report {
+	reports := make([]Report, 0)
+	if r == nil {
+		return reports
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	n := r.next
+	if r.full {
+		n = len(r.reports)
+	}
+	for i := 1; i <= n; i++ {
+		report := r.reports[(r.next-i+len(r.reports))%len(r.reports)]
+		if provider == "" || report.Provider == provider {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
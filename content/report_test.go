@@ -0,0 +1,50 @@
+package content
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReportsKeepsMostRecentReports(t *testing.T) {
+	reports := NewReports(3)
+	for i, provider := range []string{"a", "b", "a", "b", "a"} {
+		reports.Add(Report{Provider: provider, Accepted: i})
+	}
+
+	all := reports.Get("")
+	if len(all) != 3 || all[0].Accepted != 4 || all[1].Accepted != 3 || all[2].Accepted != 2 {
+		t.Errorf("Expected 3 most recent reports, but got %v", all)
+	}
+	a := reports.Get("a")
+	if len(a) != 2 || a[0].Accepted != 4 || a[1].Accepted != 2 {
+		t.Errorf("Expected most recent reports of provider a, but got %v", a)
+	}
+
+	disabled := NewReports(0)
+	disabled.Add(Report{Provider: "a"})
+	if len(disabled.Get("")) != 0 {
+		t.Error("Expected no reports to be kept")
+	}
+}
+
+func TestReportTruncatesRejections(t *testing.T) {
+	report := &Report{}
+	for i := 0; i < maxReportedRejections+5; i++ {
+		report.reject("id", errors.New("Invalid"))
+	}
+	if len(report.Rejected) != maxReportedRejections || report.RejectedTruncated != 5 {
+		t.Errorf("Expected %v rejected items and 5 truncated, but got %v and %v",
+			maxReportedRejections, len(report.Rejected), report.RejectedTruncated)
+	}
+	if report.rejections() != maxReportedRejections+5 {
+		t.Errorf("Expected all rejections to be counted, but got %v", report.rejections())
+	}
+
+	for i := 0; i < maxReportedRejections+5; i++ {
+		report.failPage("http://example.com", errors.New("Unavailable"))
+	}
+	if len(report.FailedPages) != maxReportedRejections || report.FailedPagesTruncated != 5 {
+		t.Errorf("Expected %v failed pages and 5 truncated, but got %v and %v",
+			maxReportedRejections, len(report.FailedPages), report.FailedPagesTruncated)
+	}
+}
@@ -0,0 +1,154 @@
+package content
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	// Monday
+	from := time.Date(2017, 6, 5, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2017, 6, 5, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2017, 6, 5, 10, 30, 0, 0, time.UTC)},
+		{"0 6 * * *", time.Date(2017, 6, 6, 6, 0, 0, 0, time.UTC)},
+		{"0 6 * * 1-5", time.Date(2017, 6, 6, 6, 0, 0, 0, time.UTC)},
+		{"30 8 * * 7", time.Date(2017, 6, 11, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 13 * 5", time.Date(2017, 6, 9, 12, 0, 0, 0, time.UTC)},
+		{"5,10 11 * * *", time.Date(2017, 6, 5, 11, 5, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2017, 6, 5, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2017, 6, 11, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+
+	for _, test := range tests {
+		s, err := ParseCron(test.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := s.Next(from)
+		if !got.Equal(test.want) {
+			t.Errorf("Expected %v for %q, but got %v", test.want, test.spec, got)
+		}
+	}
+}
+
+func TestParseCronRejectsInvalidExpressions(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := ParseCron(spec)
+		if err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+func TestSchedulerRefreshesProvidersIndependently(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `[{"id":"%v"}]`, requests)
+	}))
+	defer ts.Close()
+
+	config := &TestConfig{}
+	defer os.RemoveAll(filepath.Join(config.GetImportQueueDir(), "test-scheduled"))
+	defer os.RemoveAll(filepath.Join(config.GetImportQueueDir(), "test-idle"))
+	providers := Providers{
+		"test-scheduled": &Provider{ID: "test-scheduled", ContentURL: ts.URL, Native: true, schedule: IntervalSchedule(10 * time.Millisecond)},
+		"test-idle":      &Provider{ID: "test-idle", ContentURL: ts.URL, Native: true, schedule: IntervalSchedule(time.Hour)}}
+
+	index := CreateIndex(config)
+	published := make(chan *Index, 1)
+	scheduler := NewScheduler(config, providers, func() *Index { return index }, func(update func(*Index) *Index) {
+		index = update(index)
+		select {
+		case published <- index:
+		default:
+		}
+	})
+	scheduler.Start()
+
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected index to be published")
+	}
+	scheduler.Stop()
+
+	if len(index.GetProviderContent("test-scheduled")) != 1 {
+		t.Errorf("Expected scheduled provider to be refreshed, but got %v", index.GetProviderContent("test-scheduled"))
+	}
+	if len(index.GetProviderContent("test-idle")) != 0 {
+		t.Errorf("Expected idle provider not to be refreshed, but got %v", index.GetProviderContent("test-idle"))
+	}
+	if index.GetProviderLastUpdated("test-scheduled").IsZero() {
+		t.Error("Expected provider state to be updated")
+	}
+}
+
+func TestRefreshOnlyPublishesChanges(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":"0","title":"unchanged"}]`)
+	}))
+	defer ts.Close()
+
+	config := &TestConfig{}
+	defer os.RemoveAll(filepath.Join(config.GetImportQueueDir(), "test-unchanged"))
+	defer os.RemoveAll(filepath.Join(config.GetImportQueueDir(), "test-push-only"))
+	providers := Providers{
+		"test-unchanged": &Provider{ID: "test-unchanged", ContentURL: ts.URL, Native: true},
+		"test-push-only": &Provider{ID: "test-push-only"}}
+
+	index := CreateIndex(config)
+	scheduler := NewScheduler(config, providers, func() *Index { return index }, func(update func(*Index) *Index) {
+		index = update(index)
+	})
+
+	scheduler.Refresh(providers["test-unchanged"])
+	refreshed := index
+	if len(refreshed.GetProviderContent("test-unchanged")) != 1 {
+		t.Fatalf("Expected provider to be refreshed, but got %v", refreshed.GetProviderContent("test-unchanged"))
+	}
+
+	scheduler.Refresh(providers["test-push-only"])
+	if index != refreshed {
+		t.Error("Expected no index to be published for unchanged push-only provider")
+	}
+
+	scheduler.Refresh(providers["test-unchanged"])
+	if index == refreshed || index.GetID() != refreshed.GetID() {
+		t.Error("Expected view with the same ID to be published for refreshed, but unchanged content")
+	}
+	if index.GetProviderContent("test-unchanged")[0] != refreshed.GetProviderContent("test-unchanged")[0] {
+		t.Error("Expected content to be shared with the previous view")
+	}
+	if !index.GetProviderLastUpdated("test-unchanged").After(refreshed.GetProviderLastUpdated("test-unchanged")) {
+		t.Error("Expected provider state to be updated")
+	}
+
+	err := Enqueue(config, []byte(`[{"id":"1","title":"pushed"}]`), "test-push-only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler.Refresh(providers["test-push-only"])
+	if len(index.GetProviderContent("test-push-only")) != 1 || len(index.GetProviderContent("test-unchanged")) != 1 {
+		t.Errorf("Expected pushed content to be published, but got %v", index.GetContent())
+	}
+	hits, err := index.Query("unchanged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 {
+		t.Errorf("Expected content of other providers to remain searchable, but got %v hits", len(hits))
+	}
+}
@@ -0,0 +1,106 @@
+package content
+
+import (
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Scheduler refreshes the content of every provider independently according to
+// its schedule (see Provider.Schedule), and publishes a new index after each refresh.
+type Scheduler struct {
+	config    Config
+	providers Providers
+	current   func() *Index
+	publish   func(func(*Index) *Index)
+	slots     chan struct{}
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler for the provided providers. The current index is
+// obtained using the provided function. Updated indexes are published by passing an
+// update function to publish, which has to serialize updates (see server.UpdateIndex).
+func NewScheduler(config Config, providers Providers, current func() *Index, publish func(func(*Index) *Index)) *Scheduler {
+	s := &Scheduler{
+		config:    config,
+		providers: providers,
+		current:   current,
+		publish:   publish,
+		stop:      make(chan struct{})}
+	if config.GetProviderConcurrency() > 0 {
+		s.slots = make(chan struct{}, config.GetProviderConcurrency())
+	}
+	return s
+}
+
+// Start refreshes every provider in its own goroutine until the scheduler is stopped
+func (s *Scheduler) Start() {
+	for _, p := range s.providers {
+		s.wg.Add(1)
+		go s.run(p)
+	}
+}
+
+// Stop cancels all scheduled refreshes and waits for running refreshes to complete
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(provider *Provider) {
+	defer s.wg.Done()
+
+	schedule := provider.GetSchedule(s.config.GetIndexRefreshInterval())
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("No further refreshes scheduled for provider %v", provider.ID)
+			return
+		}
+		if provider.Jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(time.Second) * int64(provider.Jitter))))
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			s.Refresh(provider)
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Refresh refreshes the provider's content right away and publishes an updated index,
+// unless neither the provider's content nor its state changed (see updateProviderContent).
+// Content is fetched before the update is published, so that slow providers don't
+// hold up updates of other providers. No more than the configured number of providers
+// are fetched concurrently.
+func (s *Scheduler) Refresh(provider *Provider) {
+	var state providerState
+	if provider.ContentURL != "" {
+		if s.slots != nil {
+			s.slots <- struct{}{}
+		}
+		state = refreshProvider(s.config, provider, s.current(), true)
+		if s.slots != nil {
+			<-s.slots
+		}
+	}
+
+	s.publish(func(curIndex *Index) *Index {
+		if provider.ContentURL == "" {
+			return updateProviderContent(s.config, provider, make([]*Content, 0), nil, curIndex)
+		}
+
+		content := state.content
+		if !state.refreshed {
+			// Content may have been pushed in the meantime
+			content = curIndex.GetProviderContent(provider.ID)
+		}
+		return updateProviderContent(s.config, provider, content, &state, curIndex)
+	})
+}
@@ -0,0 +1,88 @@
+package content
+
+import "strings"
+
+// Words which are never part of extracted tags, by language
+var stopwordLists = map[string]string{
+	"en": "a about above after again against all also am an and any are aren't as at be because been before " +
+		"being below between both but by can can't could did didn't do does doesn't doing don't down during " +
+		"each even ever every few for from further get gets got had has hasn't have haven't having he her " +
+		"here hers herself him himself his how however i if in into is isn't it it's its itself just last " +
+		"least less like made make makes many may me might more most much must my myself new no nor not now " +
+		"of off often on once one only or other our ours ourselves out over own per really said same say " +
+		"says she should since so some still such than that that's the their theirs them themselves then " +
+		"there these they this those though through to too two under until up upon us very via was wasn't " +
+		"we were weren't what when where whether which while who whom whose why will with within without " +
+		"won't would yet you your yours yourself",
+	"de": "ab aber alle allem allen aller alles als also am an ander andere anderen auch auf aus bei beim " +
+		"bereits bin bis bisher bist da dabei damit dann darf das dass dem den denn der des dessen die dies " +
+		"diese diesem diesen dieser dieses doch dort du durch ein eine einem einen einer eines er es etwa " +
+		"euch euer für gegen gibt hat hatte hatten hier hin hinter ich ihm ihn ihnen ihr ihre ihrem ihren " +
+		"ihrer im in ins ist ja jetzt kann kein keine können könnte man mehr mein mit muss nach nicht noch " +
+		"nun nur ob oder ohne sagt sagte schon sehr sei seid sein seine seinem seinen seiner seit sich sie " +
+		"sind so soll sollen sondern sowie über um und uns unser unter viel vom von vor war waren warum was " +
+		"weil welche wenn wer werden wie wieder will wir wird wo wurde wurden zu zum zur zwischen",
+	"fr": "a afin ai aient ainsi alors après au aucun aussi autre aux avait avant avec avoir ce ceci cela " +
+		"celle celles celui ces cet cette chez comme comment dans de depuis des deux dont du elle elles en " +
+		"encore entre est et été être eu eux fait faire fois il ils je jusqu la le les leur leurs lors lui " +
+		"mais me même mes moi moins mon ne ni nos notre nous on ont ou où par parce pas pendant peu peut " +
+		"plus pour pourquoi qu quand que quel quelle quels qui sa sans se selon ses si son sont sous sur ta " +
+		"te tes toi ton tous tout toute toutes très tu un une vers vos votre vous y",
+	"es": "a al algo algunos ante antes aquí así aunque cada como con contra cual cuando de del desde donde " +
+		"dos durante e el ella ellas ellos en entre era eran es esa esas ese eso esos esta está están estas " +
+		"este esto estos fue fueron ha hace hacia han hasta hay la las le les lo los más me mi mientras muy " +
+		"ni no nos nosotros o otra otras otro otros para pero poco por porque puede que quien se según ser " +
+		"si sido sin sobre son su sus también tan tiene tienen todo todos tras tu un una uno unos y ya",
+	"it": "a ad agli ai al alla alle allo anche ancora che chi ci come con contro cui da dal dalla dalle " +
+		"degli dei del della delle dello di dopo dove e è ed era erano gli ha hanno i il in la le lei lo " +
+		"loro lui ma mentre mi nei nel nella nelle no noi non nostro o ogni per perché più poi quale quando " +
+		"quello questa questi questo se secondo sei senza si sia siamo sono su sua sue sul sulla suo suoi " +
+		"tra tutti tutto un una uno voi",
+	"pt": "a ao aos apenas as até com como da das de dela dele depois do dos e é ela elas ele eles em entre " +
+		"era essa esse esta está estão este eu foi foram há isso isto já la lhe mais mas me mesmo muito na " +
+		"não nas nem no nos num numa o os ou para pela pelas pelo pelos por porque qual quando que quem se " +
+		"segundo sem ser seu seus só sobre sua suas também tem têm um uma umas uns vai",
+	"nl": "aan al alle als bij dan dat de deze die dit door een en er geen had heb hebben heeft het hier hij " +
+		"hoe hun ik in is je kan kunnen maar me meer met mij na naar niet nog nu of om onder ons ook op over " +
+		"te tegen toch tot u uit van veel voor was wat we werd wie wij wil worden wordt zal ze zich zij zijn " +
+		"zo zoals zou",
+	"pl": "a aby ale bardziej być był była było były bez by co czy dla do gdy go i ich im jak jako jednak " +
+		"jej jest jego już kiedy który która które którzy lub ma mają może na nad nie niż o od oraz po pod " +
+		"przed przez przy się są ta tak także tam te tego tej ten to tu w we więc wszystko z za ze że",
+	"sv": "att av de dem den denna det detta du efter eller en ett från för har hade han hans hennes hon " +
+		"honom i inte jag kan man med men mot nu när och om på sig sin sina sitt ska skulle som så till " +
+		"under upp ut var vara vi vid vilka vill är än över",
+	"ru": "а без более бы был была были было быть в вам вас весь во вот все всего всех вы где да даже для " +
+		"до его ее если есть еще же за и из или им их к как когда кто ли либо меня мне может мы на над не " +
+		"него нет ни но о об однако он она они оно от по под после при с со так также такой там то тоже " +
+		"только том тот у уже чем что чтобы эта эти это этот я",
+	"uk": "а але без би був була були було бути в від вона вони воно все всі га де для до з за і із їх й " +
+		"його її коли лише мають має ми на над не ні но про та так також тим то тільки у уже це цей ці чи " +
+		"що щоб як який яка які я",
+}
+
+// stopwords maps the stopwords of each language, and of all languages combined
+// for content of unknown language
+var stopwords = make(map[string]map[string]bool)
+
+func init() {
+	all := make(map[string]bool)
+	for language, list := range stopwordLists {
+		words := make(map[string]bool)
+		for _, word := range strings.Fields(list) {
+			words[word] = true
+			all[word] = true
+		}
+		stopwords[language] = words
+	}
+	stopwords[""] = all
+}
+
+// stopwordsOf returns the stopwords of the content's language, or of all
+// languages if its language is unknown
+func stopwordsOf(c *Content) map[string]bool {
+	if words, ok := stopwords[localeLanguage(c.Language)]; ok {
+		return words
+	}
+	return stopwords[""]
+}
@@ -0,0 +1,297 @@
+package content
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// Name of the top-level bucket holding one nested bucket per provider
+var providersBucket = []byte("providers")
+
+// Key of a provider's bucket holding the IDs of its most recently ingested content, in order
+var currentKey = []byte("current")
+
+// Name of a provider's nested bucket holding its content, keyed by content ID
+var contentBucket = []byte("content")
+
+// Key of a provider's bucket holding the time its content was last refreshed
+var lastUpdatedKey = []byte("lastUpdated")
+
+// Maximum interval in which expired content of a provider is deleted, content may
+// be retained for up to this long after it expired
+const storeExpiryInterval = time.Hour
+
+// Store persists ingested content, so that the index can be restored after a restart
+// without having to fetch content from all providers first.
+type Store struct {
+	db        *bolt.DB
+	retention time.Duration
+	// Time expired content of each provider was last deleted
+	expired map[string]time.Time
+	mux     sync.Mutex
+}
+
+// StoredContent is a content item along with the times it was first and last ingested
+type StoredContent struct {
+	Content   Content
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// OpenStore opens (or creates) the content store file of the provided config. The
+// store must be closed when no longer in use, as the file is locked while open.
+func OpenStore(config Config) (*Store, error) {
+	db, err := bolt.Open(config.GetContentStoreFile(), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(providersBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db, retention: config.GetContentStoreRetention(), expired: make(map[string]time.Time)}, nil
+}
+
+// Close releases the store's file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save persists the content ingested from the given provider at the provided time.
+// Content no longer ingested is kept until it exceeds the configured retention, and
+// deleted in the next save (see expireDue).
+func (s *Store) Save(provider string, content []*Content, ingested time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		pb, err := tx.Bucket(providersBucket).CreateBucketIfNotExists([]byte(provider))
+		if err != nil {
+			return err
+		}
+		cb, err := pb.CreateBucketIfNotExists(contentBucket)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0)
+		for _, c := range content {
+			if c.ID == "" {
+				continue
+			}
+			ids = append(ids, c.ID)
+			item := StoredContent{Content: *c, FirstSeen: ingested, LastSeen: ingested}
+			if v := cb.Get([]byte(c.ID)); v != nil {
+				var existing StoredContent
+				if err := decodeStoredContent(v, &existing); err == nil {
+					item.FirstSeen = existing.FirstSeen
+				}
+			}
+
+			v, err := encodeStoredContent(&item)
+			if err != nil {
+				return err
+			}
+			err = cb.Put([]byte(c.ID), v)
+			if err != nil {
+				return err
+			}
+		}
+
+		var buf bytes.Buffer
+		err = gob.NewEncoder(&buf).Encode(ids)
+		if err != nil {
+			return err
+		}
+		err = pb.Put(currentKey, buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		if s.expireDue(provider, ingested) {
+			return expireStoredContent(cb, ingested.Add(-s.retention))
+		}
+		return nil
+	})
+}
+
+// expireDue returns true if the provider's expired content is to be deleted when
+// saving at the provided time. Expired content is deleted at most once per
+// storeExpiryInterval, or per retention period if shorter.
+func (s *Store) expireDue(provider string, ingested time.Time) bool {
+	if s.retention <= 0 {
+		return false
+	}
+	interval := storeExpiryInterval
+	if s.retention < interval {
+		interval = s.retention
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if last, ok := s.expired[provider]; ok && ingested.Sub(last) < interval {
+		return false
+	}
+	s.expired[provider] = ingested
+	return true
+}
+
+// Load returns the content most recently ingested from the given provider
+func (s *Store) Load(provider string) ([]*Content, error) {
+	content := make([]*Content, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		pb := tx.Bucket(providersBucket).Bucket([]byte(provider))
+		if pb == nil || pb.Get(currentKey) == nil {
+			return nil
+		}
+
+		var ids []string
+		err := gob.NewDecoder(bytes.NewReader(pb.Get(currentKey))).Decode(&ids)
+		if err != nil {
+			return err
+		}
+
+		cb := pb.Bucket(contentBucket)
+		for _, id := range ids {
+			v := cb.Get([]byte(id))
+			if v == nil {
+				continue
+			}
+			var item StoredContent
+			err := decodeStoredContent(v, &item)
+			if err != nil {
+				return err
+			}
+			content = append(content, &item.Content)
+		}
+		return nil
+	})
+	return content, err
+}
+
+// SaveLastUpdated persists the time the given provider's content was last refreshed
+func (s *Store) SaveLastUpdated(provider string, updated time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		pb, err := tx.Bucket(providersBucket).CreateBucketIfNotExists([]byte(provider))
+		if err != nil {
+			return err
+		}
+		v, err := updated.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		return pb.Put(lastUpdatedKey, v)
+	})
+}
+
+// LastUpdated returns the time the given provider's content was last refreshed,
+// the zero time if unknown
+func (s *Store) LastUpdated(provider string) (time.Time, error) {
+	var updated time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		pb := tx.Bucket(providersBucket).Bucket([]byte(provider))
+		if pb == nil {
+			return nil
+		}
+		if v := pb.Get(lastUpdatedKey); v != nil {
+			return updated.UnmarshalBinary(v)
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// History returns all retained content ever ingested from the given provider
+func (s *Store) History(provider string) ([]*StoredContent, error) {
+	items := make([]*StoredContent, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		pb := tx.Bucket(providersBucket).Bucket([]byte(provider))
+		if pb == nil || pb.Bucket(contentBucket) == nil {
+			return nil
+		}
+		return pb.Bucket(contentBucket).ForEach(func(k, v []byte) error {
+			var item StoredContent
+			err := decodeStoredContent(v, &item)
+			if err != nil {
+				return err
+			}
+			items = append(items, &item)
+			return nil
+		})
+	})
+	return items, err
+}
+
+// Restore creates an index holding the content most recently persisted for the
+// provided providers, along with the time it was last refreshed. The content is
+// served until it is refreshed by the next ingestion, and reused if a provider
+// can't be reached. The returned index keeps
+// a reference to the store, so that subsequent ingestions persist their content.
+func Restore(config Config, providers Providers, store *Store) (*Index, error) {
+	index := CreateIndex(config)
+	index.store = store
+
+	for id, provider := range providers {
+		content, err := store.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := store.LastUpdated(id)
+		if err != nil {
+			return nil, err
+		}
+		if !updated.IsZero() {
+			index.providersLastUpdated[id] = updated
+		}
+		index.setProviderPriority(id, provider.Priority)
+		err = index.Add(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	index.groupDuplicates(config.GetNearDuplicateMaxDistance())
+	index.PreLoadLocales(config.GetLocales())
+	return index, nil
+}
+
+// expireStoredContent deletes all content last ingested before the provided expiry time
+func expireStoredContent(cb *bolt.Bucket, expiry time.Time) error {
+	expired := make([][]byte, 0)
+	err := cb.ForEach(func(k, v []byte) error {
+		var item StoredContent
+		err := decodeStoredContent(v, &item)
+		if err != nil || item.LastSeen.Before(expiry) {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Keys can't be deleted while iterating a bucket
+	for _, k := range expired {
+		err = cb.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeStoredContent(item *StoredContent) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(item)
+	return buf.Bytes(), err
+}
+
+func decodeStoredContent(v []byte, item *StoredContent) error {
+	return gob.NewDecoder(bytes.NewReader(v)).Decode(item)
+}
@@ -0,0 +1,151 @@
+package content
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T, config Config) *Store {
+	os.Remove(config.GetContentStoreFile())
+	store, err := OpenStore(config)
+	if err != nil {
+		t.Fatalf("Failed to open content store: %v", err)
+	}
+	return store
+}
+
+func TestStoreSavesAndLoadsContent(t *testing.T) {
+	config := &TestConfig{}
+	store := openTestStore(t, config)
+	defer store.Close()
+
+	first := time.Now().Add(-time.Minute)
+	err := store.Save("test", []*Content{{ID: "b", Title: "t"}, {ID: "a"}}, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Save("test", []*Content{{ID: "c"}, {ID: "b", Title: "updated", Tags: []string{"t1"}}}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := store.Load("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, content, "c", "b")
+	if content[1].Title != "updated" || content[1].Tags[0] != "t1" {
+		t.Errorf("Expected updated content to be loaded, but got %v", content[1])
+	}
+
+	history, err := store.History("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected all ingested content to be retained, but got %v items", len(history))
+	}
+	for _, item := range history {
+		if item.Content.ID == "b" && !item.FirstSeen.Equal(first) {
+			t.Errorf("Expected first seen time to be retained, but got %v", item.FirstSeen)
+		}
+		if item.Content.ID == "a" && !item.LastSeen.Equal(first) {
+			t.Errorf("Expected last seen time of content no longer ingested to be retained, but got %v", item.LastSeen)
+		}
+	}
+
+	content, err = store.Load("unknown")
+	if err != nil || len(content) != 0 {
+		t.Errorf("Expected no content for unknown provider, but got %v, %v", content, err)
+	}
+}
+
+func TestStoreExpiresContent(t *testing.T) {
+	config := &TestConfig{}
+	store := openTestStore(t, config)
+	defer store.Close()
+
+	store.Save("test", []*Content{{ID: "0"}}, time.Now().Add(-2*config.GetContentStoreRetention()))
+	store.Save("test", []*Content{{ID: "1"}}, time.Now())
+
+	history, err := store.History("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Content.ID != "1" {
+		t.Errorf("Expected expired content to be deleted, but got %v", history)
+	}
+
+	// Expired content is deleted at most once per interval
+	now := time.Now()
+	if store.expireDue("test", now.Add(time.Minute)) {
+		t.Error("Expected no expiry within the interval of the last one")
+	}
+	if !store.expireDue("test", now.Add(storeExpiryInterval)) || !store.expireDue("other", now) {
+		t.Error("Expected expiry after the interval and for other providers")
+	}
+}
+
+func TestRestoreAndIngestPersistContent(t *testing.T) {
+	config := &TestConfig{}
+	store := openTestStore(t, config)
+	defer store.Close()
+
+	providers := Providers{"test-store": &Provider{ID: "test-store", ContentURL: "invalid-url"}}
+	defer os.RemoveAll(filepath.Join(config.GetImportQueueDir(), "test-store"))
+	store.Save("test-store", []*Content{{ID: "0", Source: "test-store"}}, time.Now())
+
+	index, err := Restore(config, providers, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, index.GetProviderContent("test-store"), "0")
+
+	err = Enqueue(config, []byte(`[{"id":"1"}]`), "test-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index = Ingest(config, providers, index)
+	assertContentIDs(t, index.GetContent(), "0", "1")
+
+	content, err := store.Load("test-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, content, "0", "1")
+}
+
+func TestRestoreRestoresLastUpdated(t *testing.T) {
+	config := &TestConfig{}
+	store := openTestStore(t, config)
+	defer store.Close()
+
+	providers := Providers{"test-updated": &Provider{ID: "test-updated"}, "test-unknown": &Provider{ID: "test-unknown"}}
+	updated := time.Now().Add(-time.Minute)
+	err := store.SaveLastUpdated("test-updated", updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := Restore(config, providers, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !index.GetProviderLastUpdated("test-updated").Equal(updated) {
+		t.Errorf("Expected last update to be restored, but got %v", index.GetProviderLastUpdated("test-updated"))
+	}
+	if !index.GetProviderLastUpdated("test-unknown").IsZero() {
+		t.Errorf("Expected no last update, but got %v", index.GetProviderLastUpdated("test-unknown"))
+	}
+
+	index.setProviderState("test-unknown", providerState{refreshed: true})
+	persisted, err := store.LastUpdated("test-unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !persisted.Equal(index.GetProviderLastUpdated("test-unknown")) {
+		t.Errorf("Expected last update to be persisted, but got %v", persisted)
+	}
+}
@@ -0,0 +1,150 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// FieldError describes why a field of a pushed content item is invalid
+type FieldError struct {
+	// Position of the item in the pushed content
+	Item int `json:"item"`
+
+	// ID of the item, if present
+	ID string `json:"id,omitempty"`
+
+	// JSON name of the invalid field
+	Field string `json:"field"`
+
+	// Description of the problem
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("Item %v: %v: %v", e.Item, e.Field, e.Message)
+}
+
+// timestampLayouts lists the accepted formats of publication dates
+var timestampLayouts = []string{
+	time.RFC3339,
+	time.RFC1123,
+	time.RFC1123Z,
+	time.RFC822,
+	time.RFC822Z,
+	time.RFC850,
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02"}
+
+// TimestampFormats describes the accepted formats of publication dates (see
+// timestampLayouts) in error messages
+const TimestampFormats = "RFC 3339 (e.g. 2017-09-17T13:53:05Z), RFC 1123, RFC 822, RFC 850 or " +
+	"a date and time without time zone (e.g. 2017-09-17 or 2017-09-17T13:53:05)"
+
+// ParseTimestamp parses a publication date in any of the accepted formats
+func ParseTimestamp(s string) (time.Time, error) {
+	var err error
+	for _, layout := range timestampLayouts {
+		var t time.Time
+		t, err = time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// ValidateJSON checks that the provided bytes contain an array of content items
+// in our format. An error is returned if the JSON is malformed, otherwise the
+// problems found in individual items are returned, if any.
+func ValidateJSON(bytes []byte) ([]FieldError, error) {
+	var items []json.RawMessage
+	err := json.Unmarshal(bytes, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]FieldError, 0)
+	validator := NewValidator()
+	for i, item := range items {
+		_, itemErrs := validator.Validate(i, item)
+		errs = append(errs, itemErrs...)
+	}
+	return errs, nil
+}
+
+// Validator checks individual content items, keeping track of their IDs
+// to detect duplicates.
+type Validator struct {
+	ids map[string]bool
+}
+
+// NewValidator creates a validator for a new batch of content items
+func NewValidator() *Validator {
+	return &Validator{ids: make(map[string]bool)}
+}
+
+// Validate checks a single content item in JSON format at the given position,
+// and returns its ID along with the problems found, if any.
+func (v *Validator) Validate(i int, item []byte) (string, []FieldError) {
+	c, errs := validateItem(i, item)
+	if c == nil {
+		return "", errs
+	}
+	if len(errs) == 0 {
+		if v.ids[c.ID] {
+			errs = append(errs, FieldError{Item: i, ID: c.ID, Field: "id", Message: "Duplicate ID"})
+		}
+		v.ids[c.ID] = true
+	}
+	return c.ID, errs
+}
+
+func validateItem(i int, item []byte) (*Content, []FieldError) {
+	var c Content
+	err := json.Unmarshal(item, &c)
+	if err != nil {
+		field := ""
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			field = typeErr.Field
+		}
+		return nil, []FieldError{{Item: i, Field: field, Message: err.Error()}}
+	}
+
+	errs := make([]FieldError, 0)
+	fail := func(field string, message string) {
+		errs = append(errs, FieldError{Item: i, ID: c.ID, Field: field, Message: message})
+	}
+
+	if c.ID == "" {
+		fail("id", "Required field is missing")
+	}
+	if c.Title == "" {
+		fail("title", "Required field is missing")
+	}
+	if c.URL == "" {
+		fail("url", "Required field is missing")
+	} else if !isValidURL(c.URL) {
+		fail("url", "Not a valid absolute HTTP(S) URL")
+	}
+	if c.Image != "" && !isValidURL(c.Image) {
+		fail("image_src", "Not a valid absolute HTTP(S) URL")
+	}
+	switch c.CType {
+	case "", RECOMMENDED, PROMOTED, SPONSORED:
+	default:
+		fail("type", fmt.Sprintf("Unknown type, expected one of %v, %v or %v", RECOMMENDED, PROMOTED, SPONSORED))
+	}
+	if c.Published != nil && !c.Published.Valid() {
+		fail("published_timestamp", "Not a valid date, expected "+TimestampFormats)
+	}
+	return &c, errs
+}
+
+func isValidURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,52 @@
+package content
+
+import (
+	"testing"
+)
+
+func TestValidateJSONAcceptsValidContent(t *testing.T) {
+	errs, err := ValidateJSON([]byte(`[
+		{"id":"0", "title":"t", "url":"https://mozilla.org", "type":"promoted",
+		 "published_timestamp":"2017-09-17T13:53:05Z"},
+		{"id":"1", "title":"t", "url":"http://mozilla.org", "published_timestamp":"Sun, 17 Sep 2017 13:53:05 GMT"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) > 0 {
+		t.Errorf("Expected no validation errors, but got %v", errs)
+	}
+}
+
+func TestValidateJSONRejectsMalformedJSON(t *testing.T) {
+	_, err := ValidateJSON([]byte(`{"id":"0"}`))
+	if err == nil {
+		t.Error("Expected error for JSON object instead of array")
+	}
+}
+
+func TestValidateJSONReportsFieldErrors(t *testing.T) {
+	errs, err := ValidateJSON([]byte(`[
+		{"id":"0", "url":"mozilla.org", "type":"unknown", "published_timestamp":"yesterday"},
+		{"id":"1", "title":"t", "url":"https://mozilla.org", "tags":"t1"},
+		{"id":"2", "title":"t", "url":"https://mozilla.org"},
+		{"id":"2", "title":"t", "url":"https://mozilla.org"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []FieldError{
+		{Item: 0, ID: "0", Field: "title"},
+		{Item: 0, ID: "0", Field: "url"},
+		{Item: 0, ID: "0", Field: "type"},
+		{Item: 0, ID: "0", Field: "published_timestamp"},
+		{Item: 1, Field: "tags"},
+		{Item: 3, ID: "2", Field: "id"}}
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %v validation errors, but got %v", len(want), errs)
+	}
+	for i, e := range errs {
+		if e.Item != want[i].Item || e.ID != want[i].ID || e.Field != want[i].Field || e.Message == "" {
+			t.Errorf("Expected validation error %v, but got %v", want[i], e)
+		}
+	}
+}
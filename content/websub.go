@@ -0,0 +1,395 @@
+package content
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/xml"
+	"hash"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Time after which a subscription request the hub didn't verify is sent again
+const pendingSubscriptionTimeout = time.Hour
+
+// Hub identifies the WebSub hub advertised by a provider and the topic (feed URL)
+// to subscribe to
+type Hub struct {
+	URL   string `json:"url"`
+	Topic string `json:"topic"`
+}
+
+// Subscription describes a provider's subscription at a WebSub hub
+type Subscription struct {
+	Hub       string    `json:"hub"`
+	Topic     string    `json:"topic"`
+	Requested time.Time `json:"requested"`
+	Verified  bool      `json:"verified"`
+	Expires   time.Time `json:"expires,omitempty"`
+
+	secret         string
+	previousSecret string
+	pending        bool
+	lease          time.Duration
+}
+
+// Subscriber subscribes to the WebSub hubs advertised by providers, so that their
+// content is pushed as soon as it is published instead of waiting for the next poll.
+type Subscriber struct {
+	callbackURL   string
+	lease         time.Duration
+	client        *http.Client
+	subscriptions map[string]*Subscription
+	mux           sync.Mutex
+}
+
+// NewSubscriber creates a subscriber requesting subscriptions of the provided lease.
+// The provider's ID is appended to the callback URL, no subscriptions are requested
+// if it is empty.
+func NewSubscriber(callbackURL string, lease time.Duration) *Subscriber {
+	return &Subscriber{
+		callbackURL:   callbackURL,
+		lease:         lease,
+		client:        &http.Client{Timeout: time.Second * 10},
+		subscriptions: make(map[string]*Subscription)}
+}
+
+// Subscribe requests subscriptions for all providers advertising a hub in the
+// provided index, and renews subscriptions which are about to expire
+func (s *Subscriber) Subscribe(index *Index, providers Providers) {
+	if s.callbackURL == "" {
+		return
+	}
+
+	for id := range providers {
+		hub := index.GetProviderHub(id)
+		if hub.URL == "" || !s.needsSubscription(id, hub) {
+			continue
+		}
+		err := s.subscribe(id, hub)
+		if err != nil {
+			log.Printf("Failed to subscribe to hub %v for provider %v: %v", hub.URL, id, err)
+		}
+	}
+}
+
+// GetSubscription returns the provider's subscription, if any
+func (s *Subscriber) GetSubscription(provider string) (Subscription, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	sub, ok := s.subscriptions[provider]
+	if !ok {
+		return Subscription{}, false
+	}
+	return *sub, true
+}
+
+func (s *Subscriber) needsSubscription(provider string, hub Hub) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	sub := s.subscriptions[provider]
+	if sub == nil || sub.Hub != hub.URL || sub.Topic != hub.Topic {
+		return true
+	}
+	if sub.pending && time.Since(sub.Requested) < pendingSubscriptionTimeout {
+		return false
+	}
+	if !sub.Verified {
+		return true
+	}
+	return time.Until(sub.Expires) < sub.lease/5
+}
+
+func (s *Subscriber) subscribe(provider string, hub Hub) error {
+	secret, err := newSecret()
+	if err != nil {
+		return err
+	}
+
+	// The subscription is recorded before it is requested, as hubs may verify
+	// the intent before responding. Renewals keep the previous secret, so that
+	// content pushed in the meantime is still accepted.
+	s.mux.Lock()
+	sub := &Subscription{Hub: hub.URL, Topic: hub.Topic}
+	if prev := s.subscriptions[provider]; prev != nil && prev.Hub == hub.URL && prev.Topic == hub.Topic && prev.Verified {
+		*sub = *prev
+		sub.previousSecret = prev.secret
+	}
+	sub.secret = secret
+	sub.pending = true
+	sub.Requested = time.Now()
+	s.subscriptions[provider] = sub
+	s.mux.Unlock()
+
+	resp, err := s.client.PostForm(hub.URL, url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {hub.Topic},
+		"hub.callback":      {strings.TrimSuffix(s.callbackURL, "/") + "/" + url.PathEscape(provider)},
+		"hub.secret":        {secret},
+		"hub.lease_seconds": {strconv.Itoa(int(s.lease.Seconds()))}})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+	log.Printf("Requested subscription to hub %v for provider %v", hub.URL, provider)
+	return nil
+}
+
+// Verify confirms the intent of a hub to activate the provider's subscription to
+// the given topic. It returns false if no such subscription was requested.
+func (s *Subscriber) Verify(provider string, mode string, topic string, leaseSeconds string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	sub := s.subscriptions[provider]
+	if sub == nil || sub.Topic != topic || mode != "subscribe" {
+		return false
+	}
+
+	lease := s.lease
+	if seconds, err := strconv.Atoi(leaseSeconds); err == nil && seconds > 0 {
+		lease = time.Second * time.Duration(seconds)
+	}
+	sub.Verified = true
+	sub.pending = false
+	sub.previousSecret = ""
+	sub.lease = lease
+	sub.Expires = time.Now().Add(lease)
+	log.Printf("Subscription to hub %v for provider %v verified until %v", sub.Hub, provider, sub.Expires.Format(time.RFC3339))
+	return true
+}
+
+// Deny removes the provider's subscription to the given topic after the hub denied it
+func (s *Subscriber) Deny(provider string, topic string, reason string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	sub := s.subscriptions[provider]
+	if sub != nil && sub.Topic == topic {
+		log.Printf("Subscription to hub %v for provider %v denied: %v", sub.Hub, provider, reason)
+		delete(s.subscriptions, provider)
+	}
+}
+
+// Authenticate returns true if the body was pushed by the hub of the provider's
+// verified subscription, based on the signature provided in the X-Hub-Signature
+// header e.g. sha256=...
+func (s *Subscriber) Authenticate(provider string, body []byte, signature string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	sub := s.subscriptions[provider]
+	if sub == nil || !sub.Verified {
+		return false
+	}
+
+	parts := strings.SplitN(signature, "=", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	var h func() hash.Hash
+	switch parts[0] {
+	case "sha1":
+		h = sha1.New
+	case "sha256":
+		h = sha256.New
+	case "sha384":
+		h = sha512.New384
+	case "sha512":
+		h = sha512.New
+	default:
+		return false
+	}
+	expected, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+
+	for _, secret := range []string{sub.secret, sub.previousSecret} {
+		if secret == "" {
+			continue
+		}
+		mac := hmac.New(h, []byte(secret))
+		mac.Write(body)
+		if hmac.Equal(mac.Sum(nil), expected) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessPushed parses the body pushed by the provider's hub, applying the
+// provider's processors. If enabled, pushed items are enriched with the metadata
+// of their pages. Pushed content is processed before it's merged into an index
+// (see IngestPushed), so that fetching linked pages doesn't hold up index updates.
+func ProcessPushed(config Config, provider *Provider, body []byte, curIndex *Index) ([]*Content, error) {
+	report := newReport(provider, reportTypePush)
+	client := &http.Client{Timeout: provider.GetTimeout()}
+	polite := curIndex.getPoliteness()
+	pushed, err := parseContent(body, provider, newPageFetcher(config, provider, client, polite, report), report)
+	report.finish(pushed, err)
+	curIndex.reports.Add(*report)
+	if err != nil {
+		return nil, err
+	}
+	if provider.EnrichMetadata {
+		pushed = enrichContent(config, provider, client, polite, pushed)
+	}
+	analyzeContent(config, provider, curIndex, pushed)
+	return pushed, nil
+}
+
+// IngestPushed creates a new index from the current one, merging the content
+// pushed by the provider's hub (see ProcessPushed) into the provider's content.
+// Pushed items replace existing items with the same ID, new items are added in front.
+func IngestPushed(config Config, provider *Provider, pushed []*Content, curIndex *Index) *Index {
+	index := updateProviderContent(config, provider, mergeContent(pushed, curIndex.GetProviderContent(provider.ID)), nil, curIndex)
+	log.Printf("Ingested %v items pushed by provider %v", len(pushed), provider.ID)
+	return index
+}
+
+// mergeContent returns the pushed content followed by the existing content which wasn't pushed again
+func mergeContent(pushed []*Content, existing []*Content) []*Content {
+	ids := make(map[string]bool)
+	merged := make([]*Content, 0)
+	for _, c := range pushed {
+		ids[c.ID] = true
+		merged = append(merged, c)
+	}
+	for _, c := range existing {
+		if !ids[c.ID] {
+			merged = append(merged, c)
+		}
+	}
+	return merged
+}
+
+// discoverHub finds the WebSub hub advertised in the Link header of the response,
+// the link elements of the feed or the hubs of a JSON Feed. The provider's ContentURL
+// is used as topic, unless a self link is present.
+func discoverHub(provider *Provider, header http.Header, body []byte) Hub {
+	hub, self := parseLinkHeader(header["Link"])
+	feedHub, feedSelf := parseFeedLinks(body)
+	if isJSONFeed(body) {
+		feedHub, feedSelf = parseJSONFeedLinks(body)
+	}
+	if hub == "" {
+		hub = feedHub
+	}
+	if self == "" {
+		self = feedSelf
+	}
+	if hub == "" {
+		return Hub{}
+	}
+
+	base, err := url.Parse(provider.ContentURL)
+	if err != nil {
+		return Hub{}
+	}
+	hubURL, err := base.Parse(hub)
+	if err != nil {
+		return Hub{}
+	}
+	topic := provider.ContentURL
+	if self != "" {
+		if selfURL, err := base.Parse(self); err == nil {
+			topic = selfURL.String()
+		}
+	}
+	return Hub{URL: hubURL.String(), Topic: topic}
+}
+
+// parseLinkHeader returns the hub and self links of Link headers e.g. <https://hub.example.com/>; rel="hub"
+func parseLinkHeader(values []string) (string, string) {
+	hub, self := "", ""
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = strings.Trim(target, "<>")
+
+			for _, param := range parts[1:] {
+				kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+				if len(kv) != 2 || !strings.EqualFold(kv[0], "rel") {
+					continue
+				}
+				hub, self = matchRel(strings.Trim(kv[1], `"`), target, hub, self)
+			}
+		}
+	}
+	return hub, self
+}
+
+// parseFeedLinks returns the hub and self links of an RSS or Atom feed, found in the
+// link elements preceding the first item
+func parseFeedLinks(body []byte) (string, string) {
+	hub, self := "", ""
+	d := xml.NewDecoder(bytes.NewReader(body))
+	d.Strict = false
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return hub, self
+		}
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch se.Name.Local {
+		case "item", "entry":
+			return hub, self
+		case "link":
+			var rel, href string
+			for _, attr := range se.Attr {
+				switch attr.Name.Local {
+				case "rel":
+					rel = attr.Value
+				case "href":
+					href = attr.Value
+				}
+			}
+			if href != "" {
+				hub, self = matchRel(rel, href, hub, self)
+			}
+		}
+	}
+}
+
+func matchRel(rel string, target string, hub string, self string) (string, string) {
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		if r == "hub" && hub == "" {
+			hub = target
+		} else if r == "self" && self == "" {
+			self = target
+		}
+	}
+	return hub, self
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,173 @@
+package content
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiscoverHub(t *testing.T) {
+	provider := &Provider{ID: "test", ContentURL: "https://example.com/feed"}
+
+	tests := []struct {
+		header http.Header
+		body   string
+		want   Hub
+	}{
+		{http.Header{"Link": {`<https://hub.example.com/>; rel="hub", <https://example.com/self>; rel="self"`}}, "",
+			Hub{URL: "https://hub.example.com/", Topic: "https://example.com/self"}},
+		{http.Header{}, `<feed xmlns="http://www.w3.org/2005/Atom"><link rel="hub" href="https://hub.example.com/"/><link rel="self" href="https://example.com/atom"/><entry><link rel="hub" href="https://other.example.com/"/></entry></feed>`,
+			Hub{URL: "https://hub.example.com/", Topic: "https://example.com/atom"}},
+		{http.Header{}, `<rss xmlns:atom="http://www.w3.org/2005/Atom"><channel><atom:link rel="hub" href="/hub"/><item></item></channel></rss>`,
+			Hub{URL: "https://example.com/hub", Topic: "https://example.com/feed"}},
+		{http.Header{}, `<rss><channel><link>https://example.com</link></channel></rss>`, Hub{}},
+		{http.Header{}, `{"version":"https://jsonfeed.org/version/1.1","feed_url":"https://example.com/feed.json","hubs":[{"type":"WebSub","url":"https://hub.example.com/"}],"items":[]}`,
+			Hub{URL: "https://hub.example.com/", Topic: "https://example.com/feed.json"}},
+		{http.Header{}, `[{"id":"0"}]`, Hub{}},
+	}
+
+	for _, test := range tests {
+		got := discoverHub(provider, test.header, []byte(test.body))
+		if got != test.want {
+			t.Errorf("Expected %v, but got %v", test.want, got)
+		}
+	}
+}
+
+func TestSubscriberSubscribesToHub(t *testing.T) {
+	requests := make(chan url.Values, 2)
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests <- r.PostForm
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hub.Close()
+
+	index := createIndexWithID("test")
+	index.SetProviderHub("test", Hub{URL: hub.URL, Topic: "https://example.com/feed"})
+	providers := Providers{"test": &Provider{ID: "test"}, "other": &Provider{ID: "other"}}
+
+	s := NewSubscriber("https://crec.example.com/crec/websub", time.Hour)
+	s.Subscribe(index, providers)
+
+	form := <-requests
+	if form.Get("hub.mode") != "subscribe" || form.Get("hub.topic") != "https://example.com/feed" ||
+		form.Get("hub.callback") != "https://crec.example.com/crec/websub/test" || form.Get("hub.lease_seconds") != "3600" {
+		t.Errorf("Unexpected subscription request %v", form)
+	}
+
+	// Pending subscriptions aren't requested again
+	s.Subscribe(index, providers)
+	if len(requests) > 0 {
+		t.Error("Expected no further subscription request")
+	}
+
+	body := []byte("<rss></rss>")
+	mac := hmac.New(sha256.New, []byte(form.Get("hub.secret")))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if s.Authenticate("test", body, signature) {
+		t.Error("Expected content to be rejected before subscription was verified")
+	}
+
+	if s.Verify("test", "subscribe", "https://example.com/other", "60") {
+		t.Error("Expected verification of unknown topic to fail")
+	}
+	if !s.Verify("test", "subscribe", "https://example.com/feed", "60") {
+		t.Fatal("Expected verification to succeed")
+	}
+	sub, ok := s.GetSubscription("test")
+	if !ok || !sub.Verified || time.Until(sub.Expires) > time.Minute {
+		t.Errorf("Expected verified subscription with granted lease, but got %v", sub)
+	}
+
+	if !s.Authenticate("test", body, signature) {
+		t.Error("Expected content with valid signature to be accepted")
+	}
+	if s.Authenticate("test", []byte("<rss>forged</rss>"), signature) {
+		t.Error("Expected content with invalid signature to be rejected")
+	}
+
+	// Subscriptions are renewed once the lease is about to expire
+	s.Subscribe(index, providers)
+	if len(requests) > 0 {
+		t.Error("Expected no renewal before the lease is about to expire")
+	}
+	s.subscriptions["test"].Expires = time.Now().Add(time.Second)
+	s.Subscribe(index, providers)
+	renewal := <-requests
+	if !s.Authenticate("test", body, signature) {
+		t.Error("Expected previous secret to be accepted until renewal is verified")
+	}
+	s.Verify("test", "subscribe", "https://example.com/feed", "")
+	if s.Authenticate("test", body, signature) {
+		t.Error("Expected previous secret to be rejected after renewal was verified")
+	}
+	if renewal.Get("hub.secret") == form.Get("hub.secret") {
+		t.Error("Expected new secret for renewal")
+	}
+}
+
+func TestIngestPushed(t *testing.T) {
+	config := &TestConfig{}
+	provider := &Provider{ID: "test-push"}
+	defer os.RemoveAll(filepath.Join(config.GetImportQueueDir(), "test-push"))
+
+	curIndex := CreateIndex(config)
+	curIndex.Add([]*Content{{ID: "0", Source: "test-push", Title: "old"}, {ID: "1", Source: "test-push"}, {ID: "2", Source: "other"}})
+	curIndex.SetProviderValidators("other", Validators{ETag: "e"})
+
+	body := `<rss><channel><item><guid>3</guid><title>new</title></item><item><guid>0</guid><title>updated</title></item></channel></rss>`
+	pushed, err := ProcessPushed(config, provider, []byte(body), curIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := IngestPushed(config, provider, pushed, curIndex)
+
+	assertContentIDs(t, index.GetProviderContent("test-push"), "3", "0", "1")
+	assertContentIDs(t, index.GetProviderContent("other"), "2")
+	if index.GetID() == curIndex.GetID() {
+		t.Error("Expected new index ID")
+	}
+	if index.GetProviderValidators("other").ETag != "e" {
+		t.Error("Expected provider state to be retained")
+	}
+	hits, err := index.Query("updated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContentIDs(t, hits, "0")
+
+	_, err = ProcessPushed(config, provider, []byte("not a feed"), curIndex)
+	if err == nil {
+		t.Error("Expected error for invalid feed")
+	}
+}
+
+func TestProcessPushedEnrichesContent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><meta property="og:image" content="/pushed.jpg"></head></html>`)
+	}))
+	defer ts.Close()
+
+	config := &TestConfig{}
+	provider := &Provider{ID: "test-push-enrich", EnrichMetadata: true}
+	defer os.RemoveAll(filepath.Join(config.GetImportQueueDir(), provider.ID))
+
+	body := `<rss><channel><item><guid>0</guid><title>pushed</title><link>` + ts.URL + `/pushed</link></item></channel></rss>`
+	content, err := ProcessPushed(config, provider, []byte(body), CreateIndex(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 1 || content[0].Image != ts.URL+"/pushed.jpg" {
+		t.Errorf("Expected pushed content to be enriched, but got %v", content)
+	}
+}
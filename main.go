@@ -2,7 +2,6 @@ package main
 
 import (
 	"log"
-	"time"
 
 	"flag"
 
@@ -14,6 +13,7 @@ import (
 // See: https://docs.google.com/document/d/1PjETbQVZpjtOGkE3sc8XrLUVpMVd02dG24uFqkO3itQ/
 func main() {
 	apiKeys := flag.Bool("apiKeys", false, "Generate and print API keys for providers")
+	replayImports := flag.String("replayImports", "", "Move failed imports of the given provider back into the import queue")
 	flag.Parse()
 
 	config := config.Get()
@@ -27,15 +27,46 @@ func main() {
 			log.Printf("Found provider %v with API key: %v\n", provider, apiKey)
 		}
 	}
+	if *replayImports != "" {
+		replayed, err := content.ReplayFailedImports(config, *replayImports)
+		if err != nil {
+			log.Fatal("Failed to replay imports: ", err)
+		}
+		log.Printf("Replaying %v failed imports from provider %v\n", replayed, *replayImports)
+	}
+
+	var index *content.Index
+	restored := false
+	if config.GetContentStoreFile() != "" {
+		store, err := content.OpenStore(config)
+		if err != nil {
+			log.Fatal("Failed to open content store: ", err)
+		}
+		defer store.Close()
+
+		index, err = content.Restore(config, providers, store)
+		if err != nil {
+			log.Fatal("Failed to restore content from store: ", err)
+		}
+		restored = true
+	} else {
+		index = content.Ingest(config, providers, &content.Index{})
+	}
 
-	index := content.Ingest(config, providers, &content.Index{})
 	server := server.Create(config, providers, index)
-	ticker := time.NewTicker(config.GetIndexRefreshInterval())
+	scheduler := content.NewScheduler(config, providers, server.GetIndex, func(update func(*content.Index) *content.Index) {
+		server.UpdateIndex(update)
+		server.SubscribeToHubs()
+	})
 	go func() {
-		for _ = range ticker.C {
-			index := content.Ingest(config, providers, index)
-			server.SetIndex(index)
+		// Restored content is served while providers whose content is due are refreshed
+		if restored {
+			server.UpdateIndex(func(index *content.Index) *content.Index {
+				return content.Ingest(config, providers, index)
+			})
 		}
+		server.SubscribeToHubs()
+		scheduler.Start()
 	}()
 	err = server.Start()
 	if err != nil {
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+
+	"mozilla.org/crec/content"
+)
+
+const (
+	// Number of content items enqueued together during bulk imports
+	bulkImportBatchSize = 1000
+	// Maximum size in bytes of a single line (content item) of a bulk import
+	maxBulkImportLineSize = 1024 * 1024
+)
+
+// BulkImportResponse summarizes the outcome of a bulk import
+type BulkImportResponse struct {
+	Accepted int              `json:"accepted"`
+	Rejected int              `json:"rejected"`
+	Lines    []BulkImportLine `json:"lines"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// BulkImportLine reports whether or not the content item on a line of a bulk import was
+// accepted, i.e. enqueued for indexing
+type BulkImportLine struct {
+	Line     int                  `json:"line"`
+	ID       string               `json:"id,omitempty"`
+	Accepted bool                 `json:"accepted"`
+	Errors   []content.FieldError `json:"errors,omitempty"`
+}
+
+func isBulkImport(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/x-ndjson" || mediaType == "application/ndjson"
+}
+
+// handleBulkImport reads newline-delimited JSON content items one line at a time
+// and enqueues the valid ones in batches, so large imports don't have to be held
+// in memory. Enqueued batches can't be withdrawn, so if the import fails after
+// content was enqueued, 207 (Multi-Status) is returned and the lines report which
+// items were accepted.
+func (s *Server) handleBulkImport(w http.ResponseWriter, r *http.Request, provider string) {
+	body := http.MaxBytesReader(w, r.Body, s.config.GetMaxBulkImportSize())
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxBulkImportLineSize)
+
+	response := BulkImportResponse{Lines: make([]BulkImportLine, 0)}
+	status := http.StatusOK
+	validator := content.NewValidator()
+	batch := make([][]byte, 0, bulkImportBatchSize)
+	batchLines := make([]int, 0, bulkImportBatchSize)
+
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		err := content.Enqueue(s.config, append(append([]byte("["), bytes.Join(batch, []byte(","))...), ']'), provider)
+		if err != nil {
+			log.Printf("Failed to enqueue bulk import from provider %v: %v", provider, err)
+			for _, i := range batchLines {
+				l := &response.Lines[i]
+				l.Accepted = false
+				l.Errors = []content.FieldError{{Item: l.Line, ID: l.ID, Message: "Failed to enqueue content for indexing"}}
+			}
+			response.Rejected += len(batch)
+			response.Error = "Failed to enqueue content for indexing"
+			status = http.StatusInternalServerError
+			return false
+		}
+		response.Accepted += len(batch)
+		batch = batch[:0]
+		batchLines = batchLines[:0]
+		return true
+	}
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		item := bytes.TrimSpace(scanner.Bytes())
+		if len(item) == 0 {
+			continue
+		}
+
+		id, errs := validator.Validate(line, item)
+		if len(errs) > 0 {
+			response.Rejected++
+			response.Lines = append(response.Lines, BulkImportLine{Line: line, ID: id, Errors: errs})
+			continue
+		}
+
+		// The scanner reuses its buffer, so the item has to be copied
+		batch = append(batch, append([]byte(nil), item...))
+		batchLines = append(batchLines, len(response.Lines))
+		response.Lines = append(response.Lines, BulkImportLine{Line: line, ID: id, Accepted: true})
+		if len(batch) == bulkImportBatchSize && !flush() {
+			s.respondWithBulkImportSummary(w, partialStatus(status, response), response)
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		status = http.StatusBadRequest
+		if err == bufio.ErrTooLong {
+			response.Error = fmt.Sprintf("Line %v exceeds maximum size of %v bytes", line+1, maxBulkImportLineSize)
+		} else {
+			response.Error = "Failed to read request body: " + err.Error()
+		}
+	}
+
+	flush()
+	s.respondWithBulkImportSummary(w, partialStatus(status, response), response)
+}
+
+// partialStatus returns 207 (Multi-Status) instead of the provided error status if
+// some content was enqueued nevertheless
+func partialStatus(status int, response BulkImportResponse) int {
+	if status >= http.StatusBadRequest && response.Accepted > 0 {
+		return http.StatusMultiStatus
+	}
+	return status
+}
+
+func (s *Server) respondWithBulkImportSummary(w http.ResponseWriter, status int, response BulkImportResponse) {
+	bytes, err := json.Marshal(response)
+	if err != nil {
+		log.Fatal("Failed to marshal bulk import summary to JSON: ", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(bytes)
+}
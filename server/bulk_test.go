@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleBulkImport(t *testing.T) {
+	body := `{"id":"0", "title":"t", "url":"https://mozilla.org"}
+
+{"id":"1", "title":"t"}
+not json
+{"id":"0", "title":"t", "url":"https://mozilla.org"}
+{"id":"2", "title":"t", "url":"https://mozilla.org"}
+`
+	request := httptest.NewRequest("POST", server.config.GetImportPath(), strings.NewReader(body))
+	request.Header.Set("Authorization", "APIKEY "+GenerateKey("test", server.config))
+	request.Header.Set("Content-Type", "application/x-ndjson")
+	recorder := httptest.NewRecorder()
+	server.handleImport(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 (OK), but got %v", recorder.Code)
+	}
+
+	response := BulkImportResponse{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Accepted != 2 || response.Rejected != 3 {
+		t.Errorf("Expected 2 accepted and 3 rejected items, but got %v and %v", response.Accepted, response.Rejected)
+	}
+
+	want := []BulkImportLine{
+		{Line: 1, ID: "0", Accepted: true},
+		{Line: 3, ID: "1"},
+		{Line: 4},
+		{Line: 5, ID: "0"},
+		{Line: 6, ID: "2", Accepted: true}}
+	if len(response.Lines) != len(want) {
+		t.Fatalf("Expected %v lines in summary, but got %v", len(want), response.Lines)
+	}
+	for i, l := range response.Lines {
+		if l.Line != want[i].Line || l.ID != want[i].ID || l.Accepted != want[i].Accepted {
+			t.Errorf("Expected line summary %v, but got %v", want[i], l)
+		}
+		if !l.Accepted && len(l.Errors) == 0 {
+			t.Errorf("Expected errors for rejected line %v", l.Line)
+		}
+	}
+}
+
+func TestHandleBulkImportRejectsOversizedLines(t *testing.T) {
+	body := `{"id":"0", "title":"` + strings.Repeat("t", maxBulkImportLineSize) + `", "url":"https://mozilla.org"}`
+	request := httptest.NewRequest("POST", server.config.GetImportPath(), strings.NewReader(body))
+	request.Header.Set("Authorization", "APIKEY "+GenerateKey("test", server.config))
+	request.Header.Set("Content-Type", "application/x-ndjson")
+	recorder := httptest.NewRecorder()
+	server.handleImport(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 (Bad Request), but got %v", recorder.Code)
+	}
+}
+
+func TestHandleBulkImportReportsPartialImports(t *testing.T) {
+	body := `{"id":"0", "title":"t0", "url":"https://mozilla.org"}` + "\n" +
+		`{"id":"1", "title":"` + strings.Repeat("t", maxBulkImportLineSize) + `", "url":"https://mozilla.org"}`
+	request := httptest.NewRequest("POST", server.config.GetImportPath(), strings.NewReader(body))
+	request.Header.Set("Authorization", "APIKEY "+GenerateKey("test", server.config))
+	request.Header.Set("Content-Type", "application/x-ndjson")
+	recorder := httptest.NewRecorder()
+	server.handleImport(recorder, request)
+	if recorder.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected 207 (Multi-Status), but got %v", recorder.Code)
+	}
+
+	var response BulkImportResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Accepted != 1 || len(response.Lines) != 1 || !response.Lines[0].Accepted || response.Error == "" {
+		t.Errorf("Expected first line to be reported as accepted along with the error, but got %+v", response)
+	}
+}
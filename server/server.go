@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"net/http"
 	"path/filepath"
 
@@ -18,6 +19,10 @@ import (
 
 	"reflect"
 
+	"sync"
+
+	"time"
+
 	"mozilla.org/crec/config"
 	"mozilla.org/crec/content"
 )
@@ -32,11 +37,41 @@ type Server struct {
 	config *config.AppConfig
 	// All configured content providers
 	providers content.Providers
+	// Subscriptions to WebSub hubs advertised by providers
+	subscriber *content.Subscriber
+	// Serializes index updates from scheduled ingestions and pushed content
+	updateMux sync.Mutex
 }
 
-// JSONResponse wraps content recommendations as a JSON object
+// JSONResponse wraps content recommendations as a JSON object, along with the
+// near-duplicates of recommended content from other providers
 type JSONResponse struct {
-	Recs content.Recommendations `json:"recommendations"`
+	Recs       content.Recommendations       `json:"recommendations"`
+	Alternates map[string][]*content.Content `json:"alternates,omitempty"`
+}
+
+// ImportErrorResponse explains why pushed content was rejected
+type ImportErrorResponse struct {
+	Error  string               `json:"error"`
+	Errors []content.FieldError `json:"errors,omitempty"`
+}
+
+// StatusResponse reports the status of all configured content providers
+type StatusResponse struct {
+	Providers map[string]ProviderStatus `json:"providers"`
+}
+
+// ReportResponse lists the most recent ingestion reports
+type ReportResponse struct {
+	Reports []content.Report `json:"reports"`
+}
+
+// ProviderStatus reports whether or not fetching content from a provider is
+// currently suspended, along with its failure history
+type ProviderStatus struct {
+	content.ProviderStatus
+	Tripped bool                  `json:"tripped"`
+	WebSub  *content.Subscription `json:"websub,omitempty"`
 }
 
 // Create a new server instance
@@ -47,14 +82,23 @@ func Create(config *config.AppConfig, providers content.Providers, index *conten
 		&content.ProviderBasedRecommender{},
 		&content.LocaleBasedRecommender{}}
 
-	s := Server{index: unsafe.Pointer(index),
+	callbackURL := ""
+	if config.GetPublicURL() != "" {
+		callbackURL = strings.TrimSuffix(config.GetPublicURL(), "/") + config.GetWebSubPath()
+	}
+
+	s := &Server{index: unsafe.Pointer(index),
 		recommenders: recommenders,
 		config:       config,
-		providers:    providers}
+		providers:    providers,
+		subscriber:   content.NewSubscriber(callbackURL, config.GetWebSubLease())}
 
 	http.HandleFunc(config.GetImportPath(), s.handleImport)
 	http.HandleFunc(config.GetContentPath(), s.handleContent)
-	return &s
+	http.HandleFunc(config.GetStatusPath(), s.handleStatus)
+	http.HandleFunc(config.GetReportPath(), s.handleReports)
+	http.HandleFunc(config.GetWebSubPath()+"/", s.handleWebSub)
+	return s
 }
 
 // Start a server to provide an API for importing and consuming content
@@ -78,6 +122,16 @@ func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.Method == "DELETE" {
+		s.handleRetraction(w, r, provider)
+		return
+	}
+
+	if isBulkImport(r) {
+		s.handleBulkImport(w, r, provider)
+		return
+	}
+
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -85,6 +139,16 @@ func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fieldErrs, err := content.ValidateJSON(body)
+	if err != nil {
+		s.respondWithImportErrors(w, "Malformed JSON: "+err.Error(), nil)
+		return
+	}
+	if len(fieldErrs) > 0 {
+		s.respondWithImportErrors(w, "Invalid content", fieldErrs)
+		return
+	}
+
 	err = content.Enqueue(s.config, body, provider)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -95,8 +159,35 @@ func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *Server) handleRetraction(w http.ResponseWriter, r *http.Request, provider string) {
+	ids := r.URL.Query()["id"]
+	if len(ids) == 0 {
+		s.respondWithImportErrors(w, "Missing ID of content to retract", nil)
+		return
+	}
+
+	err := content.EnqueueRetraction(s.config, ids, provider)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Failed to enqueue content retraction.\n"))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) respondWithImportErrors(w http.ResponseWriter, msg string, fieldErrs []content.FieldError) {
+	bytes, err := json.Marshal(ImportErrorResponse{Error: msg, Errors: fieldErrs})
+	if err != nil {
+		log.Fatal("Failed to marshal import errors to JSON: ", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(bytes)
+}
+
 func (s *Server) handleContent(w http.ResponseWriter, req *http.Request) {
-	index := s.getIndex()
+	index := s.GetIndex()
 	if match := req.Header.Get("If-None-Match"); match != "" {
 		if match == index.GetID() {
 			w.WriteHeader(http.StatusNotModified)
@@ -105,7 +196,21 @@ func (s *Server) handleContent(w http.ResponseWriter, req *http.Request) {
 	}
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
+	since, err := parseDateParam(req, "since")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error() + "\n"))
+		return
+	}
+	until, err := parseDateParam(req, "until")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error() + "\n"))
+		return
+	}
+
 	c, hadErrors := s.produceRecommendations(req, index)
+	c = content.Filter(c, content.PublishedFilter(since, until))
 	if !hadErrors {
 		w.Header().Set("Etag", index.GetID())
 		w.Header().Set("Cache-Control", "max-age="+s.config.GetClientCacheMaxAge()+", must-revalidate")
@@ -118,13 +223,57 @@ func (s *Server) handleContent(w http.ResponseWriter, req *http.Request) {
 	} else if strings.Contains(acceptHeader, "json") ||
 		strings.HasSuffix(acceptHeader, "*") ||
 		strings.EqualFold(format, "json") {
-		s.respondWithJSON(w, c)
+		s.respondWithJSON(w, index, c)
 	} else {
 		w.WriteHeader(http.StatusNotAcceptable)
 		w.Write([]byte("Media type " + acceptHeader + " not supported.\n"))
 	}
 }
 
+// parseDateParam returns the date of the given query parameter, zero if not present
+func parseDateParam(req *http.Request, name string) (time.Time, error) {
+	value := req.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := content.ParseTimestamp(value)
+	if err != nil {
+		return time.Time{}, errors.New("Invalid date " + value + " of parameter " + name + ", expected " + content.TimestampFormats + ".")
+	}
+	return t, nil
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, req *http.Request) {
+	index := s.GetIndex()
+	status := StatusResponse{Providers: make(map[string]ProviderStatus)}
+	for id := range s.providers {
+		providerStatus := index.GetProviderStatus(id)
+		ps := ProviderStatus{ProviderStatus: providerStatus, Tripped: providerStatus.Tripped()}
+		if sub, ok := s.subscriber.GetSubscription(id); ok {
+			ps.WebSub = &sub
+		}
+		status.Providers[id] = ps
+	}
+
+	bytes, err := json.Marshal(status)
+	if err != nil {
+		log.Fatal("Failed to marshal provider status to JSON: ", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytes)
+}
+
+func (s *Server) handleReports(w http.ResponseWriter, req *http.Request) {
+	reports := s.GetIndex().GetReports(req.URL.Query().Get("p"))
+
+	bytes, err := json.Marshal(ReportResponse{Reports: reports})
+	if err != nil {
+		log.Fatal("Failed to marshal ingestion reports to JSON: ", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytes)
+}
+
 func (s *Server) produceRecommendations(r *http.Request, index *content.Index) (content.Recommendations, bool) {
 	params := make(map[string]interface{})
 	params["lang"] = r.Header.Get("Accept-Language")
@@ -167,8 +316,15 @@ func (s *Server) respondWithHTML(w http.ResponseWriter, recs content.Recommendat
 	}
 }
 
-func (s *Server) respondWithJSON(w http.ResponseWriter, recs content.Recommendations) {
-	bytes, err := json.Marshal(JSONResponse{Recs: recs})
+func (s *Server) respondWithJSON(w http.ResponseWriter, index *content.Index, recs content.Recommendations) {
+	response := JSONResponse{Recs: recs, Alternates: make(map[string][]*content.Content)}
+	for _, rec := range recs {
+		if alternates := index.GetAlternates(rec.ID); len(alternates) > 0 {
+			response.Alternates[rec.ID] = alternates
+		}
+	}
+
+	bytes, err := json.Marshal(response)
 	if err != nil {
 		log.Fatal("Failed to marshal content to JSON: ", err)
 	}
@@ -176,11 +332,26 @@ func (s *Server) respondWithJSON(w http.ResponseWriter, recs content.Recommendat
 	w.Write(bytes)
 }
 
+// UpdateIndex replaces the server's index with the one returned by the provided
+// function. Updates are serialized, so the function always receives the latest index.
+func (s *Server) UpdateIndex(update func(*content.Index) *content.Index) {
+	s.updateMux.Lock()
+	defer s.updateMux.Unlock()
+	s.SetIndex(update(s.GetIndex()))
+}
+
+// SubscribeToHubs subscribes to the WebSub hubs advertised by providers, if
+// this server is publicly reachable (see PublicURL)
+func (s *Server) SubscribeToHubs() {
+	s.subscriber.Subscribe(s.GetIndex(), s.providers)
+}
+
 // SetIndex atomically updates the server's index to reflect updated content
 func (s *Server) SetIndex(index *content.Index) {
 	atomic.StorePointer(&s.index, unsafe.Pointer(index))
 }
 
-func (s *Server) getIndex() *content.Index {
+// GetIndex atomically loads the server's current index
+func (s *Server) GetIndex() *content.Index {
 	return (*content.Index)(atomic.LoadPointer(&s.index))
 }
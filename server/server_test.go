@@ -7,7 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"net/http"
 
@@ -153,6 +155,34 @@ func TestHandleContentProducesUniqueRecommendations(t *testing.T) {
 		}
 	}
 }
+func TestHandleContentFiltersByPublicationDate(t *testing.T) {
+	index.AddItem(&content.Content{ID: "d0", Source: "dated", Published: content.NewTimestamp(time.Date(2017, 9, 16, 0, 0, 0, 0, time.UTC))})
+	index.AddItem(&content.Content{ID: "d1", Source: "dated", Published: content.NewTimestamp(time.Date(2017, 9, 17, 0, 0, 0, 0, time.UTC))})
+	index.AddItem(&content.Content{ID: "d2", Source: "dated"})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", server.config.GetContentPath()+"?p=dated&since=2017-09-17T00:00:00Z&until=2017-09-18", nil)
+	request.Header.Set("Accept", "application/json")
+	server.handleContent(recorder, request)
+
+	response := JSONResponse{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Recs) != 1 || response.Recs[0].ID != "d1" {
+		t.Errorf("Expected content published since the given date, but got %v", response.Recs)
+	}
+
+	recorder = httptest.NewRecorder()
+	request = httptest.NewRequest("GET", server.config.GetContentPath()+"?p=dated&since=yesterday", nil)
+	request.Header.Set("Accept", "application/json")
+	server.handleContent(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 (Bad Request), but got %v", recorder.Code)
+	}
+}
+
 func TestHandleImportChecksAPIKey(t *testing.T) {
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest("GET", server.config.GetImportPath(), nil)
@@ -170,6 +200,64 @@ func TestHandleImportChecksAPIKey(t *testing.T) {
 		t.Errorf("Expected 403 (Status Forbidden), but got %v", recorder.Code)
 	}
 
+	request = httptest.NewRequest("POST", server.config.GetImportPath(),
+		strings.NewReader(`[{"id":"0", "title":"t", "url":"https://mozilla.org"}]`))
+	request.Header.Set("Authorization", "APIKEY "+apikey)
+	recorder = httptest.NewRecorder()
+	server.handleImport(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected 200 (Status OK), but got %v", recorder.Code)
+	}
+}
+
+func TestHandleImportValidatesContent(t *testing.T) {
+	apikey := GenerateKey("test", server.config)
+
+	request := httptest.NewRequest("POST", server.config.GetImportPath(), strings.NewReader(`{}`))
+	request.Header.Set("Authorization", "APIKEY "+apikey)
+	recorder := httptest.NewRecorder()
+	server.handleImport(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 (Bad Request), but got %v", recorder.Code)
+	}
+
+	request = httptest.NewRequest("POST", server.config.GetImportPath(),
+		strings.NewReader(`[{"id":"0", "title":"t", "url":"mozilla.org"}]`))
+	request.Header.Set("Authorization", "APIKEY "+apikey)
+	recorder = httptest.NewRecorder()
+	server.handleImport(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 (Bad Request), but got %v", recorder.Code)
+	}
+
+	response := ImportErrorResponse{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Errors) != 1 || response.Errors[0].Field != "url" || response.Errors[0].ID != "0" {
+		t.Errorf("Expected exactly one error for field url, but got %v", response.Errors)
+	}
+}
+
+func TestHandleImportRetractsContent(t *testing.T) {
+	apikey := GenerateKey("test", server.config)
+
+	request := httptest.NewRequest("DELETE", server.config.GetImportPath(), nil)
+	request.Header.Set("Authorization", "APIKEY "+apikey)
+	recorder := httptest.NewRecorder()
+	server.handleImport(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 (Bad Request), but got %v", recorder.Code)
+	}
+
+	request = httptest.NewRequest("DELETE", server.config.GetImportPath()+"?id=0&id=1", nil)
+	recorder = httptest.NewRecorder()
+	server.handleImport(recorder, request)
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 (Status Forbidden), but got %v", recorder.Code)
+	}
+
 	request.Header.Set("Authorization", "APIKEY "+apikey)
 	recorder = httptest.NewRecorder()
 	server.handleImport(recorder, request)
@@ -188,6 +276,7 @@ func (r *FailingRecommender) Recommend(
 }
 
 func TestCacheHeadersOmittedIfRecommenderFailing(t *testing.T) {
+	defer func(recommenders []content.Recommender) { server.recommenders = recommenders }(server.recommenders)
 	failingRecommender := &FailingRecommender{}
 	server.recommenders = append(server.recommenders, failingRecommender)
 
@@ -206,8 +295,6 @@ func TestCacheHeadersOmittedIfRecommenderFailing(t *testing.T) {
 	if recorder.Header().Get("Cache-Control") != "" {
 		t.Errorf("Expected Cache-Control header to be empty")
 	}
-
-	server.recommenders = make([]content.Recommender, 0)
 }
 
 func BenchmarkHandleContent(b *testing.B) {
@@ -217,3 +304,60 @@ func BenchmarkHandleContent(b *testing.B) {
 		server.handleContent(recorder, request)
 	}
 }
+
+func TestHandleStatusReportsSuspendedProviders(t *testing.T) {
+	index.SetProviderStatus("test", content.ProviderStatus{
+		ConsecutiveFailures: 5,
+		LastError:           "e",
+		TrippedUntil:        time.Now().Add(time.Minute)})
+	defer index.SetProviderStatus("test", content.ProviderStatus{})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", server.config.GetStatusPath(), nil)
+	server.handleStatus(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected 200 (OK), but got %v", recorder.Code)
+	}
+
+	response := StatusResponse{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, ok := response.Providers["test"]
+	if !ok {
+		t.Fatal("Expected status of provider test to be reported")
+	}
+	if !status.Tripped || status.ConsecutiveFailures != 5 || status.LastError != "e" {
+		t.Errorf("Unexpected provider status: %v", status)
+	}
+}
+
+func TestHandleReports(t *testing.T) {
+	defer server.SetIndex(server.GetIndex())
+	server.SetIndex(content.CreateIndex(server.config))
+
+	provider := &content.Provider{ID: "test-reports"}
+	defer os.RemoveAll(filepath.Join(server.config.GetImportQueueDir(), "test-reports"))
+	_, err := content.ProcessPushed(server.config, provider, []byte(`<rss><channel><item><guid>0</guid></item></channel></rss>`), server.GetIndex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", server.config.GetReportPath()+"?p=test-reports", nil)
+	server.handleReports(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected 200 (OK), but got %v", recorder.Code)
+	}
+
+	response := ReportResponse{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Reports) != 1 || response.Reports[0].Type != "push" || response.Reports[0].Accepted != 1 {
+		t.Errorf("Unexpected reports: %v", response.Reports)
+	}
+}
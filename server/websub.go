@@ -0,0 +1,88 @@
+package server
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"mozilla.org/crec/content"
+)
+
+// handleWebSub handles callbacks of WebSub hubs, i.e. the verification of
+// subscription intents (GET) and the distribution of content (POST). The
+// provider is identified by the last segment of the callback URL.
+func (s *Server) handleWebSub(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, s.config.GetWebSubPath()+"/")
+	provider, ok := s.providers[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		s.handleWebSubVerification(w, r, provider)
+	case "POST":
+		s.handleWebSubContent(w, r, provider)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleWebSubVerification(w http.ResponseWriter, r *http.Request, provider *content.Provider) {
+	q := r.URL.Query()
+	if q.Get("hub.mode") == "denied" {
+		s.subscriber.Deny(provider.ID, q.Get("hub.topic"), q.Get("hub.reason"))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	challenge := q.Get("hub.challenge")
+	if challenge == "" || !s.subscriber.Verify(provider.ID, q.Get("hub.mode"), q.Get("hub.topic"), q.Get("hub.lease_seconds")) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(challenge))
+}
+
+func (s *Server) handleWebSubContent(w http.ResponseWriter, r *http.Request, provider *content.Provider) {
+	limit := provider.MaxContentSize
+	if limit <= 0 {
+		limit = s.config.GetMaxBulkImportSize()
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Failed to read request body.\n"))
+		return
+	}
+
+	// Hubs expect a successful response even if the signature doesn't
+	// match, the content is ignored in that case.
+	if !s.subscriber.Authenticate(provider.ID, body, r.Header.Get("X-Hub-Signature")) {
+		log.Printf("Ignoring content pushed for provider %v with invalid signature", provider.ID)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// Ingestion may have to fetch linked pages and wait for a scheduled ingestion
+	// to complete, so it happens in the background to respond to the hub right away.
+	go s.ingestPushed(provider, body)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ingestPushed ingests the content pushed by the provider's hub. Content is
+// processed before the index is updated, so that slow fetches of linked pages
+// don't hold up other updates.
+func (s *Server) ingestPushed(provider *content.Provider, body []byte) {
+	pushed, err := content.ProcessPushed(s.config, provider, body, s.GetIndex())
+	if err != nil {
+		log.Printf("Failed to ingest content pushed for provider %v: %v", provider.ID, err)
+		return
+	}
+	s.UpdateIndex(func(index *content.Index) *content.Index {
+		return content.IngestPushed(s.config, provider, pushed, index)
+	})
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mozilla.org/crec/content"
+)
+
+func TestWebSubSubscriptionAndContentDistribution(t *testing.T) {
+	crec := httptest.NewServer(http.DefaultServeMux)
+	defer crec.Close()
+
+	// Stand-in hub verifying the intent of subscribers before accepting subscriptions
+	verified := make(chan url.Values, 1)
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form := r.PostForm
+		w.WriteHeader(http.StatusAccepted)
+
+		go func() {
+			callback, _ := url.Parse(form.Get("hub.callback"))
+			q := callback.Query()
+			q.Set("hub.mode", "subscribe")
+			q.Set("hub.topic", form.Get("hub.topic"))
+			q.Set("hub.challenge", "challenge-accepted")
+			q.Set("hub.lease_seconds", "3600")
+			callback.RawQuery = q.Encode()
+
+			resp, err := http.Get(callback.String())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := ioutil.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK || string(body) != "challenge-accepted" {
+				t.Errorf("Expected challenge to be echoed, but got %v: %v", resp.StatusCode, string(body))
+			}
+			verified <- form
+		}()
+	}))
+	defer hub.Close()
+
+	defer func(index *content.Index, subscriber *content.Subscriber) {
+		server.SetIndex(index)
+		server.subscriber = subscriber
+	}(server.GetIndex(), server.subscriber)
+
+	server.subscriber = content.NewSubscriber(crec.URL+server.config.GetWebSubPath(), time.Hour)
+	server.GetIndex().SetProviderHub("test", content.Hub{URL: hub.URL, Topic: "https://example.com/feed"})
+	server.SubscribeToHubs()
+
+	var form url.Values
+	select {
+	case form = <-verified:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Subscription was not verified")
+	}
+
+	resp, err := http.Get(crec.URL + server.config.GetWebSubPath() + "/test?hub.mode=subscribe&hub.topic=other&hub.challenge=c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected verification of unknown topic to be refused, but got %v", resp.StatusCode)
+	}
+
+	push := func(body string, secret string) {
+		mac := hmac.New(sha1.New, []byte(secret))
+		mac.Write([]byte(body))
+		req, _ := http.NewRequest("POST", form.Get("hub.callback"), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/rss+xml")
+		req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Errorf("Expected pushed content to be accepted, but got %v", resp.StatusCode)
+		}
+	}
+	push(`<rss><channel><item><guid>forged</guid><title>forged</title></item></channel></rss>`, "wrong-secret")
+	push(`<rss><channel><item><guid>pushed</guid><title>pushed</title></item></channel></rss>`, form.Get("hub.secret"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ids := make(map[string]bool)
+		for _, c := range server.GetIndex().GetProviderContent("test") {
+			ids[c.ID] = true
+		}
+		if ids["forged"] {
+			t.Fatal("Expected content with invalid signature to be ignored")
+		}
+		if ids["pushed"] {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Pushed content was not ingested")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestIngestPushedDoesNotHoldUpUpdates(t *testing.T) {
+	fetching := make(chan bool, 1)
+	release := make(chan bool)
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetching <- true
+		<-release
+		w.Write([]byte(`<html><head><meta property="og:image" content="/pushed.jpg"></head></html>`))
+	}))
+	defer page.Close()
+
+	defer server.SetIndex(server.GetIndex())
+	provider := &content.Provider{ID: "test-push-slow", EnrichMetadata: true}
+	defer os.RemoveAll(filepath.Join(server.config.GetImportQueueDir(), provider.ID))
+
+	ingested := make(chan bool)
+	go func() {
+		server.ingestPushed(provider, []byte(`<rss><channel><item><guid>0</guid><title>pushed</title><link>`+page.URL+`/pushed</link></item></channel></rss>`))
+		ingested <- true
+	}()
+	<-fetching
+
+	updated := make(chan bool)
+	go func() {
+		server.UpdateIndex(func(index *content.Index) *content.Index { return index })
+		updated <- true
+	}()
+	select {
+	case <-updated:
+	case <-time.After(5 * time.Second):
+		t.Error("Expected index update not to wait for pushed content to be processed")
+	}
+
+	close(release)
+	<-ingested
+	pushed := server.GetIndex().GetProviderContent(provider.ID)
+	if len(pushed) != 1 || pushed[0].Image != page.URL+"/pushed.jpg" {
+		t.Errorf("Expected pushed content to be ingested, but got %v", pushed)
+	}
+}